@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"os"
+	"time"
+)
+
+// issue or revoke partner api credential.
+//
+//	usage: credential -partner <partnerId> [-description <text>]
+//	       credential -revoke <keyId>
+func main() {
+	var (
+		partnerID   = flag.String("partner", "", "partner id of the new api credential")
+		description = flag.String("description", "", "api credential description")
+		revoke      = flag.String("revoke", "", "key id of api credential to be revoked")
+	)
+	configs.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *partnerID == "" && *revoke == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := configs.Initialize(); err != nil {
+		exitWithError(fmt.Sprintf("error on config initialization: %s", err.Error()))
+	}
+
+	if err := db.Mongo.Connect(); err != nil {
+		exitWithError(fmt.Sprintf("error on mongodb connection: %s", err.Error()))
+	}
+	defer db.Mongo.Disconnect()
+
+	repo := repository.NewCredentialRepository()
+
+	if *revoke != "" {
+		if err := repo.Revoke(context.Background(), *revoke); err != nil {
+			exitWithError(err.Error())
+		}
+		fmt.Printf("api credential %s has been revoked, running api processes reject it within 1 minute\n", *revoke)
+		return
+	}
+
+	keyID, secret, err := crypt.GenerateAPIKey()
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	signingSecret, err := crypt.GenerateSigningSecret()
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	repo.Entity = &entity.PartnerCredential{
+		KeyID:         keyID,
+		KeyHash:       crypt.HashAPIKeySecret(secret),
+		SigningSecret: signingSecret,
+		PartnerID:     *partnerID,
+		Description:   *description,
+		Active:        true,
+		CreatedAt:     time.Now().UnixMilli(),
+	}
+	repo.Entity.UpdatedAt = repo.Entity.CreatedAt
+
+	if _, err = repo.Create(context.Background()); err != nil {
+		exitWithError(err.Error())
+	}
+
+	fmt.Printf("partnerId     : %s\nkeyId         : %s\napiKey        : %s.%s\nsigningSecret : %s\n",
+		*partnerID, keyID, keyID, secret, signingSecret)
+	fmt.Println("store the api key and signing secret securely, it cannot be retrieved again.")
+}
+
+func exitWithError(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
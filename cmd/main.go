@@ -1,18 +1,26 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"github.com/dw-account-service/configs"
 	"github.com/dw-account-service/internal"
 	"github.com/dw-account-service/internal/db"
 	"github.com/dw-account-service/internal/kafka"
 	"github.com/dw-account-service/internal/routes"
+	"github.com/dw-account-service/internal/scheduler"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/webhook"
 	"sync"
 )
 
 func main() {
 	var err error
+
+	// every config setting can be overridden by flag, e.g. -kafka.brokers
+	configs.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
 	internal.SetupCloseHandler()
 
 	defer internal.ExitGracefully()
@@ -20,33 +28,46 @@ func main() {
 	// Service Initialization
 	err = configs.Initialize()
 	if err != nil {
-		utilities.Log.Fatalln(fmt.Sprintf("error on config initialization: %s", err.Error()))
+		utilities.Log.Fatal().Err(err).Msg("error on config initialization")
+	}
+
+	if err = tracing.Initialize(); err != nil {
+		utilities.Log.Fatal().Err(err).Msg("error on tracing initialization")
 	}
 
 	if err = db.Mongo.Connect(); err != nil {
-		utilities.Log.Fatalln(fmt.Sprintf("error on mongodb connection: %s", err.Error()))
+		utilities.Log.Fatal().Err(err).Msg("error on mongodb connection")
 	}
 
 	wg := &sync.WaitGroup{}
 
-	// Start Messages Producer
-	wg.Add(1)
-	go func() {
-		err = kafka.Initialize()
-		if err != nil {
-			utilities.Log.Fatalln(err)
+	if configs.MainConfig.RunWorker() {
+		// Start Messages Producer, before the consumer since results are published with it
+		if err = kafka.Initialize(); err != nil {
+			utilities.Log.Fatal().Err(err).Msg("error on kafka producer initialization")
 		}
-		wg.Done()
-	}()
 
-	kafka.StartConsumer()
+		kafka.StartConsumer()
+
+		// Start Daily Balance Snapshot
+		if configs.MainConfig.Snapshot.Enable {
+			if err = scheduler.StartDailySnapshot(); err != nil {
+				utilities.Log.Fatal().Err(err).Msg("error on daily balance snapshot scheduling")
+			}
+		}
+
+		// Start Webhook Delivery
+		if configs.MainConfig.Webhook.Enable {
+			webhook.StartDeliveryWorker()
+		}
+	}
 
-	// Start Rest API
+	// Start Rest API, worker role only serve probes and metrics
 	wg.Add(1)
 	go func() {
 		err = routes.Initialize()
 		if err != nil {
-			utilities.Log.Fatalln(err)
+			utilities.Log.Fatal().Err(err).Msg("error on rest api initialization")
 		}
 
 		wg.Done()
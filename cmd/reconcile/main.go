@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/reconciliation"
+	"os"
+	"time"
+)
+
+// reconcile partner settlement file against processed top-up transactions, and print the report as json.
+//
+//	usage: reconcile -partner <partnerId> -file <settlement.csv> [-start YYYYMMDD] [-end YYYYMMDD] [-out report.json]
+func main() {
+	var (
+		partnerID = flag.String("partner", "", "partner id of the settlement file")
+		filePath  = flag.String("file", "", "path to partner settlement csv file")
+		start     = flag.String("start", "", "reconciliation start period (YYYYMMDD), default: earliest partnerTransDate")
+		end       = flag.String("end", "", "reconciliation end period (YYYYMMDD), default: latest partnerTransDate")
+		out       = flag.String("out", "", "write report to file instead of stdout")
+	)
+	configs.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *partnerID == "" || *filePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var startDate, endDate time.Time
+	var err error
+	if *start != "" {
+		if startDate, err = time.ParseInLocation("20060102", *start, time.Now().Location()); err != nil {
+			exitWithError("invalid start periods")
+		}
+	}
+	if *end != "" {
+		if endDate, err = time.ParseInLocation("20060102150405", *end+"235959", time.Now().Location()); err != nil {
+			exitWithError("invalid end periods")
+		}
+	}
+
+	if err = configs.Initialize(); err != nil {
+		exitWithError(fmt.Sprintf("error on config initialization: %s", err.Error()))
+	}
+
+	if err = db.Mongo.Connect(); err != nil {
+		exitWithError(fmt.Sprintf("error on mongodb connection: %s", err.Error()))
+	}
+	defer db.Mongo.Disconnect()
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+	defer file.Close()
+
+	records, err := reconciliation.ParseSettlementFile(file)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	report, err := reconciliation.Reconcile(context.Background(), *partnerID, records, startDate, endDate)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	result, _ := json.MarshalIndent(report, "", "  ")
+	if *out != "" {
+		if err = os.WriteFile(*out, result, 0644); err != nil {
+			exitWithError(err.Error())
+		}
+	} else {
+		fmt.Println(string(result))
+	}
+
+	fmt.Fprintf(os.Stderr, "matched: %d, missing on our side: %d, missing on partner side: %d, amount mismatched: %d, duplicate on our side: %d, duplicate on partner side: %d\n",
+		report.Summary.Matched,
+		report.Summary.MissingOnOurSide,
+		report.Summary.MissingOnPartnerSide,
+		report.Summary.AmountMismatched,
+		report.Summary.DuplicateOnOurSide,
+		report.Summary.DuplicateOnPartnerSide,
+	)
+}
+
+func exitWithError(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
@@ -4,8 +4,6 @@ import (
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/spf13/viper"
 	"log"
-	"os"
-	"strings"
 )
 
 type ServerConfig struct {
@@ -21,16 +19,41 @@ type DBConfig struct {
 	Mongo MongoConfig `mapstructure:"mongodb"`
 }
 
+type KafkaOAuthConfig struct {
+	// token endpoint of oauth2 client credentials grant
+	TokenURL     string `mapstructure:"tokenUrl"`
+	ClientID     string `mapstructure:"clientId"`
+	ClientSecret string `mapstructure:"clientSecret"`
+	// scopes: comma separated list
+	Scopes string `mapstructure:"scopes"`
+}
+
 type KafkaSASLConfig struct {
-	Enable       bool   `mapstructure:"enable"`
-	Algorithm    string `mapstructure:"algorithm"`
+	Enable bool `mapstructure:"enable"`
+	// mechanism: SCRAM | PLAIN | OAUTHBEARER, default: SCRAM
+	Mechanism string `mapstructure:"mechanism"`
+	// algorithm of SCRAM mechanism: sha256 | sha512, default: sha256
+	Algorithm string `mapstructure:"algorithm"`
+	// user & password of SCRAM and PLAIN mechanism
 	SASLUserName string `mapstructure:"user"`
 	SASLPassword string `mapstructure:"password"`
+	// oauth client of OAUTHBEARER mechanism
+	OAuth KafkaOAuthConfig `mapstructure:"oauth"`
 }
 
 type KafkaTlsConfig struct {
-	Enable             bool `mapstructure:"enable"`
-	InsecureSkipVerify bool `mapstructure:"enable"`
+	Enable bool `mapstructure:"enable"`
+	// insecureSkipVerify: skip broker certificate verification, for development only
+	InsecureSkipVerify bool `mapstructure:"insecureSkipVerify"`
+	// caFile: PEM CA bundle to verify broker certificates, default: system roots
+	CAFile string `mapstructure:"caFile"`
+	// certFile & keyFile: PEM client certificate and its key for mutual TLS
+	CertFile string `mapstructure:"certFile"`
+	KeyFile  string `mapstructure:"keyFile"`
+	// serverName: expected host name of broker certificates, default: host of the broker address
+	ServerName string `mapstructure:"serverName"`
+	// minVersion: 1.2 | 1.3, default: 1.2
+	MinVersion string `mapstructure:"minVersion"`
 }
 
 type KafkaProducerConfig struct {
@@ -47,7 +70,7 @@ type KafkaConsumerConfig struct {
 }
 
 type KafkaConfig struct {
-	// mode: producer|consumer|both
+	// mode: producer (api role) | consumer (worker role) | both, used when role isn't set
 	Mode string `mapstructure:"mode"`
 	// brokers: comma separated list
 	Brokers  string              `mapstructure:"brokers"`
@@ -57,16 +80,123 @@ type KafkaConfig struct {
 	Consumer KafkaConsumerConfig `mapstructure:"consumer"`
 }
 
+type APIKeyAuthConfig struct {
+	Enable bool `mapstructure:"enable"`
+}
+
+type SignatureAuthConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// maximum difference between request timestamp and server time
+	ToleranceSeconds int `mapstructure:"toleranceSeconds"`
+	// nonceStore: mongodb | memory, default: mongodb. memory store is only valid for single api process
+	NonceStore string `mapstructure:"nonceStore"`
+}
+
+type JWTAuthConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// jwksUrl or publicKeyFile (PEM) is used to verify token signature
+	JWKSUrl       string `mapstructure:"jwksUrl"`
+	PublicKeyFile string `mapstructure:"publicKeyFile"`
+	Issuer        string `mapstructure:"issuer"`
+	Audience      string `mapstructure:"audience"`
+	// rolesClaim: claim name (dot separated path for nested claim, e.g. realm_access.roles)
+	RolesClaim   string `mapstructure:"rolesClaim"`
+	PartnerClaim string `mapstructure:"partnerClaim"`
+}
+
+type AuthConfig struct {
+	APIKey    APIKeyAuthConfig    `mapstructure:"apiKey"`
+	Signature SignatureAuthConfig `mapstructure:"signature"`
+	JWT       JWTAuthConfig       `mapstructure:"jwt"`
+}
+
+type RateLimitRule struct {
+	// rate: number of request per second, 0 means unlimited
+	Rate float64 `mapstructure:"rate"`
+	// burst: maximum number of request at once (bucket size), default: rate
+	Burst int `mapstructure:"burst"`
+}
+
+type RateLimitConfig struct {
+	Enable  bool          `mapstructure:"enable"`
+	Default RateLimitRule `mapstructure:"default"`
+	// groups: rule per route group (first path segment after /api/v1, e.g. account, merchant, admin)
+	Groups map[string]RateLimitRule `mapstructure:"groups"`
+}
+
+type WebhookConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// maximum delivery attempts before its marked as callback failed
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// retry delay is doubled on every failed attempt, from initialBackoffSeconds up to maxBackoffSeconds
+	InitialBackoffSeconds int `mapstructure:"initialBackoffSeconds"`
+	MaxBackoffSeconds     int `mapstructure:"maxBackoffSeconds"`
+	TimeoutSeconds        int `mapstructure:"timeoutSeconds"`
+}
+
+type SnapshotConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// runAt: hh:mm, daily snapshot time for previous day closing balances
+	RunAt string `mapstructure:"runAt"`
+}
+
+type HealthConfig struct {
+	// maxConsumerLag: readiness fails when lag of any claimed partition exceeds it, 0 means lag is not checked
+	MaxConsumerLag int64 `mapstructure:"maxConsumerLag"`
+	// timeout of each dependency check
+	TimeoutSeconds int `mapstructure:"timeoutSeconds"`
+}
+
+type ShutdownConfig struct {
+	// timeoutSeconds: max duration to drain in-flight requests and messages, default: 30
+	TimeoutSeconds int `mapstructure:"timeoutSeconds"`
+}
+
+type TracingConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// exporter: otlp | stdout | none
+	Exporter string `mapstructure:"exporter"`
+	// endpoint: host:port of otlp http collector, default: localhost:4318
+	Endpoint string `mapstructure:"endpoint"`
+	Insecure bool   `mapstructure:"insecure"`
+	// sampleRatio: ratio of sampled traces (0 - 1), parent sampling decision is respected, default: 1
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+type LogRotationConfig struct {
+	// log file is rotated when its size reach maxSizeMB, default: 100
+	MaxSizeMB int `mapstructure:"maxSizeMB"`
+	// number of rotated files to be kept, default: 7
+	MaxBackups int `mapstructure:"maxBackups"`
+	// rotated files older than maxAgeDays are removed, default: 30
+	MaxAgeDays int  `mapstructure:"maxAgeDays"`
+	Compress   bool `mapstructure:"compress"`
+}
+
 type AppConfig struct {
 	AppName   string `mapstructure:"appName"`
 	DebugMode bool   `mapstructure:"debugMode"`
+	// role: api | worker | all, see Role
+	ServiceRole string `mapstructure:"role"`
 	// os | file
-	LogOutput          string       `mapstructure:"logOutput"`
-	LogPath            string       `mapstructure:"logPath"`
-	VerboseAPIResponse bool         `mapstructure:"verboseApiResponse"`
-	APIServer          ServerConfig `mapstructure:"server"`
-	Database           DBConfig     `mapstructure:"database"`
-	Kafka              KafkaConfig  `mapstructure:"kafka"`
+	LogOutput string `mapstructure:"logOutput"`
+	LogPath   string `mapstructure:"logPath"`
+	// json | text
+	LogFormat string `mapstructure:"logFormat"`
+	// debug | info | warn | error, default: info
+	LogLevel           string            `mapstructure:"logLevel"`
+	LogRotation        LogRotationConfig `mapstructure:"logRotation"`
+	VerboseAPIResponse bool              `mapstructure:"verboseApiResponse"`
+	APIServer          ServerConfig      `mapstructure:"server"`
+	Database           DBConfig          `mapstructure:"database"`
+	Kafka              KafkaConfig       `mapstructure:"kafka"`
+	Snapshot           SnapshotConfig    `mapstructure:"snapshot"`
+	Auth               AuthConfig        `mapstructure:"auth"`
+	RateLimit          RateLimitConfig   `mapstructure:"rateLimit"`
+	Webhook            WebhookConfig     `mapstructure:"webhook"`
+	Health             HealthConfig      `mapstructure:"health"`
+	Tracing            TracingConfig     `mapstructure:"tracing"`
+	Shutdown           ShutdownConfig    `mapstructure:"shutdown"`
 }
 
 var MainConfig AppConfig
@@ -77,7 +207,14 @@ func Initialize() error {
 	viper.AddConfigPath("./")
 	viper.SetConfigName("config")
 
+	// config file is optional, every setting can be set from env or flag
 	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return err
+		}
+	}
+
+	if err := bindOverrides(); err != nil {
 		return err
 	}
 
@@ -86,32 +223,19 @@ func Initialize() error {
 		return err
 	}
 
-	// --- override config from os-env if its defined ---
-	if os.Getenv("DATABASE_MONGODB_URI") != "" {
-		MainConfig.Database.Mongo.Uri = os.Getenv("DATABASE_MONGODB_URI")
-	}
-
-	if os.Getenv("DATABASE_MONGODB_DB_NAME") != "" {
-		MainConfig.Database.Mongo.DBName = os.Getenv("DATABASE_MONGODB_DB_NAME")
-	}
-
-	if os.Getenv("KAFKA_BROKERS") != "" {
-		MainConfig.Kafka.Brokers = os.Getenv("KAFKA_BROKERS")
-	}
-
-	if os.Getenv("KAFKA_SASL_USER") != "" {
-		MainConfig.Kafka.SASL.SASLUserName = os.Getenv("KAFKA_SASL_USER")
-	}
-
-	if os.Getenv("KAFKA_SASL_PASSWORD") != "" {
-		MainConfig.Kafka.SASL.SASLPassword = os.Getenv("KAFKA_SASL_PASSWORD")
+	if err = MainConfig.Validate(); err != nil {
+		return err
 	}
-	// --- end config overrides ---
 
 	err = (&utilities.AppLogger{
+		Output:      MainConfig.LogOutput,
 		LogPath:     MainConfig.LogPath,
-		CompressLog: true,
-		DailyRotate: true,
+		Format:      MainConfig.LogFormat,
+		Level:       MainConfig.LogLevel,
+		MaxSizeMB:   MainConfig.LogRotation.MaxSizeMB,
+		MaxBackups:  MainConfig.LogRotation.MaxBackups,
+		MaxAgeDays:  MainConfig.LogRotation.MaxAgeDays,
+		CompressLog: MainConfig.LogRotation.Compress,
 	}).SetAppLogger()
 
 	if err != nil {
@@ -119,8 +243,6 @@ func Initialize() error {
 		return err
 	}
 
-	utilities.Log.SetPrefix("[INIT-APP] ")
-	utilities.Log.Println(strings.Repeat("-", 40))
-	utilities.Log.Println("| configuration >> loaded")
+	utilities.Component("init-app").Info().Str("role", MainConfig.Role()).Msg("configuration loaded")
 	return nil
 }
@@ -0,0 +1,139 @@
+package configs
+
+import (
+	"flag"
+	"fmt"
+	"github.com/spf13/viper"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// setting is a leaf value of AppConfig, path is its config key (e.g. database.mongodb.dbName)
+type setting struct {
+	path string
+	env  string
+	kind reflect.Kind
+}
+
+// envAliases replace env name of the setting
+var envAliases = map[string]string{
+	"role": "APP_ROLE",
+}
+
+// flags is flag set registered by RegisterFlags, flagged keeps name of the registered flags
+var (
+	flags   *flag.FlagSet
+	flagged = map[string]bool{}
+)
+
+// settings returns every overridable setting of the config type, map settings (e.g. rateLimit.groups)
+// can only be set from config file
+func settings(t reflect.Type, prefix string) []setting {
+	var result []setting
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			result = append(result, settings(field.Type, path)...)
+		case reflect.Map, reflect.Slice:
+		default:
+			result = append(result, setting{path: path, env: envName(path), kind: field.Type.Kind()})
+		}
+	}
+
+	return result
+}
+
+// envName returns env name of the config key, e.g. database.mongodb.dbName: DATABASE_MONGODB_DB_NAME
+func envName(path string) string {
+	if alias, ok := envAliases[path]; ok {
+		return alias
+	}
+
+	var b strings.Builder
+	runes := []rune(path)
+	for i, r := range runes {
+		switch {
+		case r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != '.' && !unicode.IsUpper(runes[i-1]) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return b.String()
+}
+
+// RegisterFlags register flag of every setting into the flag set (e.g. -kafka.brokers), it must be called before
+// the flag set is parsed. flag value has precedence over env and config file
+func RegisterFlags(fs *flag.FlagSet) {
+	for _, s := range settings(reflect.TypeOf(AppConfig{}), "") {
+		usage := fmt.Sprintf("overrides %s config (env: %s)", s.path, s.env)
+
+		switch s.kind {
+		case reflect.Bool:
+			fs.Bool(s.path, false, usage)
+		case reflect.Int, reflect.Int64:
+			fs.Int64(s.path, 0, usage)
+		case reflect.Float64:
+			fs.Float64(s.path, 0, usage)
+		default:
+			fs.String(s.path, "", usage)
+		}
+		flagged[s.path] = true
+	}
+
+	flags = fs
+}
+
+// bindOverrides bind env of every setting, then set settings of <ENV>_FILE secret files and parsed flags.
+// precedence: flag, env or secret file, config file
+func bindOverrides() error {
+	for _, s := range settings(reflect.TypeOf(AppConfig{}), "") {
+		if err := viper.BindEnv(s.path, s.env); err != nil {
+			return err
+		}
+
+		file := os.Getenv(s.env + "_FILE")
+		if file == "" {
+			continue
+		}
+
+		if os.Getenv(s.env) != "" {
+			return fmt.Errorf("both %s and %s_FILE are set, only one of them can be used", s.env, s.env)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("cannot read %s_FILE: %s", s.env, err.Error())
+		}
+		viper.Set(s.path, strings.TrimRight(string(content), "\r\n"))
+	}
+
+	if flags != nil && flags.Parsed() {
+		flags.Visit(func(f *flag.Flag) {
+			if flagged[f.Name] {
+				viper.Set(f.Name, f.Value.(flag.Getter).Get())
+			}
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package configs
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		// env names used before settings were derived from the config type
+		"database.mongodb.uri":             "DATABASE_MONGODB_URI",
+		"kafka.sasl.user":                  "KAFKA_SASL_USER",
+		"kafka.sasl.password":              "KAFKA_SASL_PASSWORD",
+		"database.mongodb.dbName":          "DATABASE_MONGODB_DB_NAME",
+		"kafka.consumer.consumerGroupName": "KAFKA_CONSUMER_CONSUMER_GROUP_NAME",
+		"kafka.sasl.oauth.clientSecret":    "KAFKA_SASL_OAUTH_CLIENT_SECRET",
+		"logRotation.maxSizeMB":            "LOG_ROTATION_MAX_SIZE_MB",
+		"verboseApiResponse":               "VERBOSE_API_RESPONSE",
+		"auth.signature.toleranceSeconds":  "AUTH_SIGNATURE_TOLERANCE_SECONDS",
+		"role":                             "APP_ROLE",
+	}
+
+	envs := map[string]string{}
+	for _, s := range settings(reflect.TypeOf(AppConfig{}), "") {
+		envs[s.path] = s.env
+	}
+
+	for path, want := range tests {
+		if got, ok := envs[path]; !ok || got != want {
+			t.Errorf("env of %s = %q, want %q", path, got, want)
+		}
+	}
+
+	// map settings are only set from config file
+	for path := range envs {
+		if strings.HasPrefix(path, "rateLimit.groups") {
+			t.Errorf("got env of map setting %s", path)
+		}
+	}
+}
+
+// resetOverrides clear viper and registered flags after the test
+func resetOverrides(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(func() {
+		viper.Reset()
+		flags = nil
+	})
+}
+
+func secretFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestBindOverrides(t *testing.T) {
+	resetOverrides(t)
+	viper.Set("kafka.sasl.password", "from-config")
+
+	t.Setenv("DATABASE_MONGODB_URI", "mongodb://env:27017")
+	t.Setenv("KAFKA_SASL_USER_FILE", secretFile(t, "file-user\n"))
+	t.Setenv("KAFKA_SASL_PASSWORD_FILE", secretFile(t, "file-password"))
+	t.Setenv("APP_ROLE", "worker")
+
+	if err := bindOverrides(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"database.mongodb.uri": "mongodb://env:27017",
+		"kafka.sasl.user":      "file-user",
+		// secret file has precedence over config file
+		"kafka.sasl.password": "file-password",
+		"role":                "worker",
+	}
+	for key, want := range tests {
+		if got := viper.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestBindOverridesEnvAndFile(t *testing.T) {
+	resetOverrides(t)
+	t.Setenv("KAFKA_SASL_USER", "env-user")
+	t.Setenv("KAFKA_SASL_USER_FILE", secretFile(t, "file-user"))
+
+	err := bindOverrides()
+	if err == nil || !strings.Contains(err.Error(), "both KAFKA_SASL_USER and KAFKA_SASL_USER_FILE are set") {
+		t.Errorf("got error %v, want env and file conflict", err)
+	}
+}
+
+func TestBindOverridesMissingFile(t *testing.T) {
+	resetOverrides(t)
+	t.Setenv("KAFKA_SASL_USER_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	if err := bindOverrides(); err == nil || !strings.Contains(err.Error(), "cannot read KAFKA_SASL_USER_FILE") {
+		t.Errorf("got error %v, want unreadable file error", err)
+	}
+}
+
+func TestBindOverridesFlag(t *testing.T) {
+	resetOverrides(t)
+	viper.Set("shutdown.timeoutSeconds", 10)
+	t.Setenv("KAFKA_BROKERS", "env:9092")
+	t.Setenv("KAFKA_SASL_USER_FILE", secretFile(t, "file-user"))
+	t.Setenv("DATABASE_MONGODB_DB_NAME", "env-db")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	err := fs.Parse([]string{
+		"-kafka.brokers=flag:9092",
+		"-kafka.sasl.user=flag-user",
+		"-shutdown.timeoutSeconds=60",
+		"-kafka.sasl.enable",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = bindOverrides(); err != nil {
+		t.Fatal(err)
+	}
+
+	var conf AppConfig
+	if err = viper.Unmarshal(&conf); err != nil {
+		t.Fatal(err)
+	}
+
+	// flag has precedence over env, secret file and config file, setting without flag keeps its env
+	if conf.Kafka.Brokers != "flag:9092" || conf.Kafka.SASL.SASLUserName != "flag-user" ||
+		conf.Shutdown.TimeoutSeconds != 60 || !conf.Kafka.SASL.Enable || conf.Database.Mongo.DBName != "env-db" {
+		t.Errorf("got brokers %q, user %q, timeout %d, sasl %v, db %q",
+			conf.Kafka.Brokers, conf.Kafka.SASL.SASLUserName, conf.Shutdown.TimeoutSeconds, conf.Kafka.SASL.Enable, conf.Database.Mongo.DBName)
+	}
+}
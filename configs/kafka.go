@@ -2,38 +2,117 @@ package configs
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"github.com/Shopify/sarama"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/google/uuid"
+	"os"
+	"strings"
 )
 
-func NewSaramaConfig() *sarama.Config {
+// sasl mechanisms, see KafkaSASLConfig
+const (
+	SASLMechanismSCRAM       = "SCRAM"
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismOAuthBearer = "OAUTHBEARER"
+)
+
+func NewSaramaConfig() (*sarama.Config, error) {
 	uClientID, _ := uuid.NewUUID()
 	cfg := sarama.NewConfig()
 
 	cfg.Version = sarama.V0_11_0_2
 	cfg.ClientID = fmt.Sprintf("mdw-client-%s", uClientID)
 	cfg.Metadata.Full = true
+	cfg.Net.MaxOpenRequests = 1
+
+	if MainConfig.Kafka.SASL.Enable {
+		setSASL(cfg, MainConfig.Kafka.SASL)
+	}
+
+	if MainConfig.Kafka.TLS.Enable {
+		tlsConfig, err := newTLSConfig(MainConfig.Kafka.TLS)
+		if err != nil {
+			return nil, err
+		}
 
-	cfg.Net.SASL.Enable = MainConfig.Kafka.SASL.Enable
-	cfg.Net.SASL.User = MainConfig.Kafka.SASL.SASLUserName
-	cfg.Net.SASL.Password = MainConfig.Kafka.SASL.SASLPassword
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = tlsConfig
+	}
+
+	return cfg, nil
+}
+
+func setSASL(cfg *sarama.Config, conf KafkaSASLConfig) {
+	cfg.Net.SASL.Enable = true
 	cfg.Net.SASL.Handshake = true
-	cfg.Net.MaxOpenRequests = 1
 
-	cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
-	cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &utilities.XDGSCRAMClient{HashGeneratorFcn: utilities.SHA256} }
+	switch strings.ToUpper(conf.Mechanism) {
+	case SASLMechanismPlain:
+		cfg.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		cfg.Net.SASL.User = conf.SASLUserName
+		cfg.Net.SASL.Password = conf.SASLPassword
+	case SASLMechanismOAuthBearer:
+		var scopes []string
+		if conf.OAuth.Scopes != "" {
+			scopes = strings.Split(conf.OAuth.Scopes, ",")
+		}
+
+		cfg.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		cfg.Net.SASL.TokenProvider = utilities.NewOAuthBearerTokenProvider(
+			conf.OAuth.TokenURL,
+			conf.OAuth.ClientID,
+			conf.OAuth.ClientSecret,
+			scopes,
+		)
+	default:
+		cfg.Net.SASL.User = conf.SASLUserName
+		cfg.Net.SASL.Password = conf.SASLPassword
+
+		cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &utilities.XDGSCRAMClient{HashGeneratorFcn: utilities.SHA256} }
+
+		if conf.Algorithm == "sha512" {
+			cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &utilities.XDGSCRAMClient{HashGeneratorFcn: utilities.SHA512} }
+		}
+	}
+}
+
+// newTLSConfig returns tls config that verify broker certificates with the CA bundle (or system roots),
+// and present the client certificate when its configured
+func newTLSConfig(conf KafkaTlsConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+		ServerName:         conf.ServerName,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if conf.MinVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if conf.CAFile != "" {
+		ca, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read kafka.tls.caFile: %s", err.Error())
+		}
 
-	if MainConfig.Kafka.SASL.Algorithm == "sha512" {
-		cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
-		cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &utilities.XDGSCRAMClient{HashGeneratorFcn: utilities.SHA512} }
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("kafka.tls.caFile %s doesn't contain any PEM certificate", conf.CAFile)
+		}
+		tlsConfig.RootCAs = pool
 	}
 
-	cfg.Net.TLS.Enable = MainConfig.Kafka.TLS.Enable
-	cfg.Net.TLS.Config = &tls.Config{
-		InsecureSkipVerify: MainConfig.Kafka.TLS.Enable,
+	if conf.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load kafka.tls client certificate: %s", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
-	return cfg
+	return tlsConfig, nil
 }
@@ -0,0 +1,43 @@
+package configs
+
+// service roles, a process can run the rest api, the transaction worker (kafka consumer, producer of its results
+// and background jobs), or both. workers can be scaled independently of the rest api
+const (
+	RoleAPI    = "api"
+	RoleWorker = "worker"
+	RoleAll    = "all"
+)
+
+// kafka modes, mapped into service role when role isn't set
+const (
+	KafkaModeProducer = "producer"
+	KafkaModeConsumer = "consumer"
+	KafkaModeBoth     = "both"
+)
+
+// Role returns role of the process, kafka.mode is used when role isn't set:
+// producer (api), consumer (worker), both (all). default: all
+func (c AppConfig) Role() string {
+	if c.ServiceRole != "" {
+		return c.ServiceRole
+	}
+
+	switch c.Kafka.Mode {
+	case KafkaModeProducer:
+		return RoleAPI
+	case KafkaModeConsumer:
+		return RoleWorker
+	default:
+		return RoleAll
+	}
+}
+
+// RunAPI returns whether the process serves the rest api
+func (c AppConfig) RunAPI() bool {
+	return c.Role() == RoleAPI || c.Role() == RoleAll
+}
+
+// RunWorker returns whether the process consumes transaction requests and runs background jobs
+func (c AppConfig) RunWorker() bool {
+	return c.Role() == RoleWorker || c.Role() == RoleAll
+}
@@ -0,0 +1,192 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError list every invalid setting of the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+type problems []string
+
+func (p *problems) addf(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p *problems) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		p.addf("%s is required", key)
+	}
+}
+
+// oneOf check value is one of allowed values, empty value is valid when its listed
+func (p *problems) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+
+	var listed []string
+	for _, a := range allowed {
+		if a != "" {
+			listed = append(listed, a)
+		}
+	}
+	p.addf("%s: unknown value %q, expected one of %s", key, value, strings.Join(listed, "|"))
+}
+
+func (p *problems) nonNegative(key string, value int64) {
+	if value < 0 {
+		p.addf("%s must not be negative", key)
+	}
+}
+
+// Validate check every setting used by the role of the process, all problems are returned at once
+func (c AppConfig) Validate() error {
+	var p problems
+
+	p.oneOf("role", c.ServiceRole, "", RoleAPI, RoleWorker, RoleAll)
+	p.oneOf("kafka.mode", c.Kafka.Mode, "", KafkaModeProducer, KafkaModeConsumer, KafkaModeBoth)
+
+	// server serves probes and metrics on every role
+	if port, err := strconv.Atoi(c.APIServer.Port); err != nil || port < 1 || port > 65535 {
+		p.addf("server.port: invalid port %q", c.APIServer.Port)
+	}
+
+	p.required("database.mongodb.uri", c.Database.Mongo.Uri)
+	p.required("database.mongodb.dbName", c.Database.Mongo.DBName)
+
+	p.oneOf("logOutput", c.LogOutput, "", "os", "file")
+	if c.LogOutput != "os" {
+		p.required("logPath", c.LogPath)
+	}
+	p.oneOf("logFormat", c.LogFormat, "", "json", "text")
+	p.oneOf("logLevel", strings.ToLower(c.LogLevel), "", "trace", "debug", "info", "warn", "error")
+	p.nonNegative("logRotation.maxSizeMB", int64(c.LogRotation.MaxSizeMB))
+	p.nonNegative("logRotation.maxBackups", int64(c.LogRotation.MaxBackups))
+	p.nonNegative("logRotation.maxAgeDays", int64(c.LogRotation.MaxAgeDays))
+
+	if c.RunAPI() {
+		c.validateAPI(&p)
+	}
+
+	if c.RunWorker() {
+		c.validateWorker(&p)
+	}
+
+	p.nonNegative("health.maxConsumerLag", c.Health.MaxConsumerLag)
+	p.nonNegative("health.timeoutSeconds", int64(c.Health.TimeoutSeconds))
+	p.nonNegative("shutdown.timeoutSeconds", int64(c.Shutdown.TimeoutSeconds))
+
+	if c.Tracing.Enable {
+		p.oneOf("tracing.exporter", c.Tracing.Exporter, "", "otlp", "stdout", "none")
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			p.addf("tracing.sampleRatio must be between 0 and 1")
+		}
+	}
+
+	if len(p) > 0 {
+		return &ValidationError{Problems: p}
+	}
+
+	return nil
+}
+
+func (c AppConfig) validateAPI(p *problems) {
+	// every api route requires authenticated caller
+	if !c.Auth.APIKey.Enable && !c.Auth.JWT.Enable {
+		p.addf("auth: auth.apiKey or auth.jwt must be enabled")
+	}
+
+	p.nonNegative("auth.signature.toleranceSeconds", int64(c.Auth.Signature.ToleranceSeconds))
+	p.oneOf("auth.signature.nonceStore", c.Auth.Signature.NonceStore, "", "mongodb", "memory")
+
+	// request is signed with signing secret of the api key credential
+	if c.Auth.Signature.Enable && !c.Auth.APIKey.Enable {
+		p.addf("auth.signature: requires auth.apiKey to be enabled")
+	}
+
+	if c.Auth.JWT.Enable {
+		switch {
+		case c.Auth.JWT.JWKSUrl == "" && c.Auth.JWT.PublicKeyFile == "":
+			p.addf("auth.jwt: jwksUrl or publicKeyFile is required")
+		case c.Auth.JWT.JWKSUrl != "" && c.Auth.JWT.PublicKeyFile != "":
+			p.addf("auth.jwt: only one of jwksUrl or publicKeyFile can be set")
+		}
+	}
+
+	if c.RateLimit.Enable {
+		rules := map[string]RateLimitRule{"rateLimit.default": c.RateLimit.Default}
+		keys := []string{"rateLimit.default"}
+		for group, rule := range c.RateLimit.Groups {
+			rules["rateLimit.groups."+group] = rule
+			keys = append(keys, "rateLimit.groups."+group)
+		}
+		sort.Strings(keys[1:])
+
+		for _, key := range keys {
+			rule := rules[key]
+			if rule.Rate < 0 {
+				p.addf("%s.rate must not be negative", key)
+			}
+			p.nonNegative(key+".burst", int64(rule.Burst))
+		}
+	}
+}
+
+func (c AppConfig) validateWorker(p *problems) {
+	p.required("kafka.brokers", c.Kafka.Brokers)
+	p.required("kafka.consumer.topics", c.Kafka.Consumer.ConsumerTopics)
+	p.required("kafka.consumer.consumerGroupName", c.Kafka.Consumer.ConsumerGroupName)
+	p.oneOf("kafka.consumer.assignor", c.Kafka.Consumer.Assignor, "sticky", "roundRobin", "range")
+	p.nonNegative("kafka.producer.retryMax", int64(c.Kafka.Producer.RetryMax))
+
+	if c.Kafka.SASL.Enable {
+		mechanism := strings.ToUpper(c.Kafka.SASL.Mechanism)
+		p.oneOf("kafka.sasl.mechanism", mechanism, "", SASLMechanismSCRAM, SASLMechanismPlain, SASLMechanismOAuthBearer)
+
+		switch mechanism {
+		case SASLMechanismOAuthBearer:
+			p.required("kafka.sasl.oauth.tokenUrl", c.Kafka.SASL.OAuth.TokenURL)
+			p.required("kafka.sasl.oauth.clientId", c.Kafka.SASL.OAuth.ClientID)
+			p.required("kafka.sasl.oauth.clientSecret", c.Kafka.SASL.OAuth.ClientSecret)
+		case "", SASLMechanismSCRAM:
+			p.oneOf("kafka.sasl.algorithm", c.Kafka.SASL.Algorithm, "", "sha256", "sha512")
+			fallthrough
+		case SASLMechanismPlain:
+			p.required("kafka.sasl.user", c.Kafka.SASL.SASLUserName)
+			p.required("kafka.sasl.password", c.Kafka.SASL.SASLPassword)
+		}
+	}
+
+	if c.Kafka.TLS.Enable {
+		p.oneOf("kafka.tls.minVersion", c.Kafka.TLS.MinVersion, "", "1.2", "1.3")
+		if (c.Kafka.TLS.CertFile == "") != (c.Kafka.TLS.KeyFile == "") {
+			p.addf("kafka.tls: certFile and keyFile must be set together")
+		}
+	}
+
+	if c.Snapshot.Enable && c.Snapshot.RunAt != "" {
+		if _, err := time.Parse("15:04", c.Snapshot.RunAt); err != nil {
+			p.addf("snapshot.runAt: invalid time %q, expected hh:mm", c.Snapshot.RunAt)
+		}
+	}
+
+	if c.Webhook.Enable {
+		p.nonNegative("webhook.maxAttempts", int64(c.Webhook.MaxAttempts))
+		p.nonNegative("webhook.initialBackoffSeconds", int64(c.Webhook.InitialBackoffSeconds))
+		p.nonNegative("webhook.maxBackoffSeconds", int64(c.Webhook.MaxBackoffSeconds))
+		p.nonNegative("webhook.timeoutSeconds", int64(c.Webhook.TimeoutSeconds))
+	}
+}
@@ -0,0 +1,88 @@
+package configs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func apiConfig(auth AuthConfig) AppConfig {
+	conf := AppConfig{
+		ServiceRole: RoleAPI,
+		LogOutput:   "os",
+		Auth:        auth,
+	}
+	conf.APIServer.Port = "8080"
+	conf.Database.Mongo.Uri = "mongodb://localhost:27017"
+	conf.Database.Mongo.DBName = "account"
+	return conf
+}
+
+func TestValidateAuth(t *testing.T) {
+	apiKey := APIKeyAuthConfig{Enable: true}
+	jwks := JWTAuthConfig{Enable: true, JWKSUrl: "https://idp.example.com/jwks"}
+
+	tests := []struct {
+		name string
+		auth AuthConfig
+		want []string
+	}{
+		{"api key", AuthConfig{APIKey: apiKey}, nil},
+		{"jwt with jwks", AuthConfig{JWT: jwks}, nil},
+		{"jwt with public key", AuthConfig{JWT: JWTAuthConfig{Enable: true, PublicKeyFile: "jwt.pem"}}, nil},
+		{"api key and jwt", AuthConfig{APIKey: apiKey, JWT: jwks}, nil},
+		{"signed api key", AuthConfig{APIKey: apiKey, Signature: SignatureAuthConfig{Enable: true, NonceStore: "memory"}}, nil},
+		{"no authentication", AuthConfig{}, []string{"auth: auth.apiKey or auth.jwt must be enabled"}},
+		{"disabled signature without api key", AuthConfig{JWT: jwks, Signature: SignatureAuthConfig{NonceStore: "mongodb"}}, nil},
+		{"signature without api key", AuthConfig{JWT: jwks, Signature: SignatureAuthConfig{Enable: true}},
+			[]string{"auth.signature: requires auth.apiKey to be enabled"}},
+		{"signature only", AuthConfig{Signature: SignatureAuthConfig{Enable: true}},
+			[]string{"auth: auth.apiKey or auth.jwt must be enabled", "auth.signature: requires auth.apiKey to be enabled"}},
+		{"unknown nonce store", AuthConfig{APIKey: apiKey, Signature: SignatureAuthConfig{Enable: true, NonceStore: "redis"}},
+			[]string{`auth.signature.nonceStore: unknown value "redis", expected one of mongodb|memory`}},
+		{"negative tolerance", AuthConfig{APIKey: apiKey, Signature: SignatureAuthConfig{Enable: true, ToleranceSeconds: -1}},
+			[]string{"auth.signature.toleranceSeconds must not be negative"}},
+		{"jwt without key", AuthConfig{JWT: JWTAuthConfig{Enable: true}}, []string{"auth.jwt: jwksUrl or publicKeyFile is required"}},
+		{"jwt with both keys", AuthConfig{JWT: JWTAuthConfig{Enable: true, JWKSUrl: jwks.JWKSUrl, PublicKeyFile: "jwt.pem"}},
+			[]string{"auth.jwt: only one of jwksUrl or publicKeyFile can be set"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apiConfig(tt.auth).Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("got error %v, want valid", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("got error %v, want validation error", err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.want) {
+				t.Errorf("got problems %q, want %q", validationErr.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAuthByRole(t *testing.T) {
+	// authentication is only required by the rest api
+	conf := apiConfig(AuthConfig{})
+	conf.ServiceRole = RoleWorker
+	conf.Kafka.Brokers = "localhost:9092"
+	conf.Kafka.Consumer.ConsumerTopics = "mdw.transaction.topup.request"
+	conf.Kafka.Consumer.ConsumerGroupName = "account-service"
+	conf.Kafka.Consumer.Assignor = "sticky"
+
+	if err := conf.Validate(); err != nil {
+		t.Errorf("got error %v, want valid worker", err)
+	}
+
+	conf.ServiceRole = RoleAll
+	if err := conf.Validate(); err == nil {
+		t.Error("got valid, want authentication to be required when the rest api is served")
+	}
+}
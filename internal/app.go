@@ -1,27 +1,93 @@
 package internal
 
 import (
+	"context"
+	"github.com/dw-account-service/configs"
 	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/handlers"
 	"github.com/dw-account-service/internal/kafka"
+	"github.com/dw-account-service/internal/routes"
+	"github.com/dw-account-service/internal/scheduler"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
-	"log"
+	"github.com/dw-account-service/internal/webhook"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
-func ExitGracefully() {
-	// close mongodb connection
-	utilities.Log.SetPrefix("[EXIT-APP] ")
-	if err := db.Mongo.Disconnect(); err != nil {
-		log.Println(err.Error())
-		return
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdown make sure the service is only stopped once, by signal handler or main
+var shutdown sync.Once
+
+func shutdownTimeout() time.Duration {
+	if configs.MainConfig.Shutdown.TimeoutSeconds > 0 {
+		return time.Duration(configs.MainConfig.Shutdown.TimeoutSeconds) * time.Second
 	}
-	utilities.Log.Println("| db connection successfully closed")
+	return defaultShutdownTimeout
+}
+
+// ExitGracefully stop the service in order: stop accepting requests, wait for async bulk registration jobs, stop consuming
+// messages (in-flight balance distributions are finished or checkpointed), stop webhook delivery and daily snapshot,
+// flush the producer, then disconnect mongodb. draining every background work is bounded by shutdown.timeoutSeconds
+func ExitGracefully() {
+	shutdown.Do(func() {
+		logger := utilities.Component("exit-app")
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+		defer cancel()
+
+		// stop rest api, in-flight requests are finished
+		if err := routes.Shutdown(ctx); err != nil {
+			logger.Error().Err(err).Msg("failed to drain rest api requests")
+		} else {
+			logger.Info().Msg("rest api successfully stopped")
+		}
+
+		// wait for async bulk registration, unfinished jobs are interrupted with their progress stored
+		if err := handlers.StopBulkJobs(ctx); err != nil {
+			logger.Error().Err(err).Msg("failed to stop bulk registration jobs")
+		}
+
+		// stop consumer, in-flight message is handled before leaving the consumer group
+		if err := kafka.StopConsumer(ctx); err != nil {
+			logger.Error().Err(err).Msg("failed to stop kafka consumer")
+		} else {
+			logger.Info().Msg("kafka consumer successfully stopped")
+		}
+
+		// stop webhook delivery, in-flight callbacks are finished
+		if err := webhook.StopDeliveryWorker(ctx); err != nil {
+			logger.Error().Err(err).Msg("failed to stop webhook delivery worker")
+		}
+
+		// stop daily snapshot, running snapshot is cancelled
+		if err := scheduler.StopDailySnapshot(ctx); err != nil {
+			logger.Error().Err(err).Msg("failed to stop daily balance snapshot")
+		}
+
+		// close kafka connection
+		if err := kafka.CloseProducer(); err != nil {
+			logger.Error().Err(err).Msg("failed to close kafka producer")
+		} else {
+			logger.Info().Msg("kafka producer successfully closed")
+		}
+
+		// close mongodb connection
+		if err := db.Mongo.Disconnect(); err != nil {
+			logger.Error().Err(err).Msg("failed to close db connection")
+		} else {
+			logger.Info().Msg("db connection successfully closed")
+		}
 
-	// close kafka connection
-	_ = kafka.Producer.Close()
-	utilities.Log.Println("| kafka producer successfully closed")
+		// flush remaining spans
+		if err := tracing.Shutdown(); err != nil {
+			logger.Error().Err(err).Msg("failed to flush traces")
+		}
+	})
 }
 
 // SetupCloseHandler :
@@ -31,8 +97,7 @@ func SetupCloseHandler() {
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
-		utilities.Log.SetPrefix("[EXIT-APP] ")
-		utilities.Log.Println("| Ctrl+C pressed in Terminal,... Good Bye...")
+		utilities.Component("exit-app").Info().Msg("Ctrl+C pressed in Terminal,... Good Bye...")
 		ExitGracefully()
 		os.Exit(0)
 	}()
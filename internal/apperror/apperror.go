@@ -0,0 +1,157 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Code is stable machine-readable error code, returned as errorCode field of error response
+type Code string
+
+const (
+	CodeValidation         Code = "VALIDATION_ERROR"
+	CodeInvalidPeriod      Code = "INVALID_PERIOD"
+	CodeUnauthorized       Code = "UNAUTHORIZED"
+	CodeForbidden          Code = "FORBIDDEN"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeAccountNotFound    Code = "ACCOUNT_NOT_FOUND"
+	CodeDuplicate          Code = "DUPLICATE"
+	CodeAccountDeactivated Code = "ACCOUNT_DEACTIVATED"
+	CodeInsufficientFunds  Code = "INSUFFICIENT_FUNDS"
+	CodeConflict           Code = "CONFLICT"
+	CodeRefNumberReused    Code = "REF_NUMBER_REUSED"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeTimeout            Code = "TIMEOUT"
+	CodeUnavailable        Code = "SERVICE_UNAVAILABLE"
+	CodeInternal           Code = "INTERNAL_ERROR"
+)
+
+// statuses maps error code into http status
+var statuses = map[Code]int{
+	CodeValidation:         fiber.StatusBadRequest,
+	CodeInvalidPeriod:      fiber.StatusBadRequest,
+	CodeUnauthorized:       fiber.StatusUnauthorized,
+	CodeForbidden:          fiber.StatusForbidden,
+	CodeNotFound:           fiber.StatusNotFound,
+	CodeAccountNotFound:    fiber.StatusNotFound,
+	CodeDuplicate:          fiber.StatusConflict,
+	CodeAccountDeactivated: fiber.StatusUnprocessableEntity,
+	CodeInsufficientFunds:  fiber.StatusUnprocessableEntity,
+	CodeConflict:           fiber.StatusConflict,
+	CodeRefNumberReused:    fiber.StatusUnprocessableEntity,
+	CodeRateLimited:        fiber.StatusTooManyRequests,
+	CodeTimeout:            fiber.StatusGatewayTimeout,
+	CodeUnavailable:        fiber.StatusServiceUnavailable,
+	CodeInternal:           fiber.StatusInternalServerError,
+}
+
+// trxStatuses maps error code into status of transaction result, other code is TrxStatusFailed
+var trxStatuses = map[Code]string{
+	CodeValidation:         utilities.TrxStatusInvalidParams,
+	CodeInvalidPeriod:      utilities.TrxStatusInvalidParams,
+	CodeDuplicate:          utilities.TrxStatusInvalidParams,
+	CodeAccountNotFound:    utilities.TrxStatusInvalidAccount,
+	CodeAccountDeactivated: utilities.TrxStatusInvalidAccount,
+	CodeInsufficientFunds:  utilities.TrxStatusInsufficientFund,
+}
+
+// internalMessage is returned to the client instead of message of internal error, the cause is only logged
+const internalMessage = "internal server error"
+
+var (
+	ErrAccountNotFound      = New(CodeAccountNotFound, "account not found")
+	ErrAccountAlreadyExists = New(CodeDuplicate, "account already exists, or its probably in deactivated status")
+	ErrAccountDeactivated   = New(CodeAccountDeactivated, "account is deactivated")
+	ErrInsufficientFunds    = New(CodeInsufficientFunds, "insufficient account balance")
+)
+
+// Error is domain error with its error code
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap returns domain error of the cause error
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+// ClientMessage returns message of the error that is safe to be returned to the client,
+// internal error is replaced with generic message so its cause is not exposed
+func (e *Error) ClientMessage() string {
+	if e.Code == CodeInternal {
+		return internalMessage
+	}
+	return e.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches domain error with the same code, so errors.Is(err, ErrAccountNotFound) is true for any account not found error
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Status returns http status of the error
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return fiber.StatusInternalServerError
+}
+
+// TrxStatus returns status of transaction result of the error
+func (e *Error) TrxStatus() string {
+	if status, ok := trxStatuses[e.Code]; ok {
+		return status
+	}
+	return utilities.TrxStatusFailed
+}
+
+// From returns domain error of any error, database errors are converted into its domain error
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(CodeTimeout, "context deadline exceeded", err)
+	case errors.Is(err, mongo.ErrClientDisconnected):
+		return Wrap(CodeUnavailable, "client disconnected", err)
+	case mongo.IsNetworkError(err):
+		return Wrap(CodeUnavailable, "error connection occurred", err)
+	case mongo.IsTimeout(err):
+		return Wrap(CodeTimeout, "context deadline exceeded", err)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return Wrap(CodeNotFound, "entity not found", err)
+	case mongo.IsDuplicateKeyError(err):
+		return Wrap(CodeDuplicate, "entity already exists", err)
+	}
+
+	return Wrap(CodeInternal, "", err)
+}
+
+// Validation returns validation error of the message
+func Validation(message string) *Error {
+	return New(CodeValidation, message)
+}
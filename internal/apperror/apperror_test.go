@@ -0,0 +1,87 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		code      Code
+		status    int
+		trxStatus string
+	}{
+		{CodeValidation, fiber.StatusBadRequest, utilities.TrxStatusInvalidParams},
+		{CodeInvalidPeriod, fiber.StatusBadRequest, utilities.TrxStatusInvalidParams},
+		{CodeUnauthorized, fiber.StatusUnauthorized, utilities.TrxStatusFailed},
+		{CodeForbidden, fiber.StatusForbidden, utilities.TrxStatusFailed},
+		{CodeNotFound, fiber.StatusNotFound, utilities.TrxStatusFailed},
+		{CodeAccountNotFound, fiber.StatusNotFound, utilities.TrxStatusInvalidAccount},
+		{CodeDuplicate, fiber.StatusConflict, utilities.TrxStatusInvalidParams},
+		{CodeAccountDeactivated, fiber.StatusUnprocessableEntity, utilities.TrxStatusInvalidAccount},
+		{CodeInsufficientFunds, fiber.StatusUnprocessableEntity, utilities.TrxStatusInsufficientFund},
+		{CodeConflict, fiber.StatusConflict, utilities.TrxStatusFailed},
+		{CodeRefNumberReused, fiber.StatusUnprocessableEntity, utilities.TrxStatusFailed},
+		{CodeRateLimited, fiber.StatusTooManyRequests, utilities.TrxStatusFailed},
+		{CodeTimeout, fiber.StatusGatewayTimeout, utilities.TrxStatusFailed},
+		{CodeUnavailable, fiber.StatusServiceUnavailable, utilities.TrxStatusFailed},
+		{CodeInternal, fiber.StatusInternalServerError, utilities.TrxStatusFailed},
+		{Code("UNKNOWN"), fiber.StatusInternalServerError, utilities.TrxStatusFailed},
+	}
+
+	for _, tt := range tests {
+		err := New(tt.code, "message")
+		if got := err.Status(); got != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.code, got, tt.status)
+		}
+		if got := err.TrxStatus(); got != tt.trxStatus {
+			t.Errorf("%s: got transaction status %s, want %s", tt.code, got, tt.trxStatus)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	cause := errors.New("connection(10.0.0.5:27017) socket was unexpectedly closed")
+
+	tests := []struct {
+		name    string
+		err     error
+		code    Code
+		message string
+	}{
+		{"domain error", ErrInsufficientFunds, CodeInsufficientFunds, "insufficient account balance"},
+		{"wrapped domain error", fmt.Errorf("debit: %w", ErrAccountDeactivated), CodeAccountDeactivated, "account is deactivated"},
+		{"deadline exceeded", context.DeadlineExceeded, CodeTimeout, "context deadline exceeded"},
+		{"client disconnected", mongo.ErrClientDisconnected, CodeUnavailable, "client disconnected"},
+		{"no documents", mongo.ErrNoDocuments, CodeNotFound, "entity not found"},
+		{"duplicate key", mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}, CodeDuplicate, "entity already exists"},
+		{"internal error", cause, CodeInternal, internalMessage},
+		{"explicit internal message", Wrap(CodeInternal, "failed to get total active members", cause), CodeInternal, internalMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := From(tt.err)
+			if appErr.Code != tt.code {
+				t.Errorf("got code %s, want %s", appErr.Code, tt.code)
+			}
+			if got := appErr.ClientMessage(); got != tt.message {
+				t.Errorf("got client message %q, want %q", got, tt.message)
+			}
+			if !errors.Is(appErr, New(tt.code, "")) {
+				t.Errorf("error doesn't match code %s", tt.code)
+			}
+		})
+	}
+
+	// cause of internal error is kept for logging
+	if appErr := From(cause); appErr.Error() != cause.Error() || !errors.Is(appErr, cause) {
+		t.Errorf("internal error doesn't keep its cause: %v", appErr)
+	}
+}
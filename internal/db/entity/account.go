@@ -9,16 +9,16 @@ type AccountBalance struct {
 	UniqueID string `json:"uniqueId,omitempty" bson:"uniqueId"`
 
 	// Id platform yang bekerjasama dengan wallet system (dalam hal ini MDL)
-	PartnerID string `json:"partnerId,omitempty" bson:"partnerId"`
+	PartnerID string `json:"partnerId,omitempty" bson:"partnerId" validate:"required"`
 
 	// Id merchant yang terdaftar pada platform tersebut
 	// dalam hal ini adalah organisasi/instansi/perusahaan yang bekerjasama dengan platform MDL
-	MerchantID string `json:"merchantId,omitempty" bson:"merchantId"`
+	MerchantID string `json:"merchantId,omitempty" bson:"merchantId" validate:"required"`
 
 	// TerminalID adalah unique id yang didapat dari client,
 	// bisa berupa user id, dan atau yang lainnya
 	// yang nantinya dijadikan sebagai pencarian dan proses transaksi lainnya
-	TerminalID string `json:"terminalId,omitempty" bson:"terminalId"`
+	TerminalID string `json:"terminalId,omitempty" bson:"terminalId" validate:"required_if=Type 1"`
 
 	// TerminalName adalah deskripsi dari terminal id yang di kirim,
 	// field ini bersifat optional
@@ -31,7 +31,7 @@ type AccountBalance struct {
 	Active bool `json:"active" bson:"active"`
 
 	// Tipe Wallet pengguna, expected value -->> 1:Regular Account, 2: Merchant Account
-	Type int `json:"type" bson:"type"`
+	Type int `json:"type" bson:"type" validate:"required,oneof=1 2"`
 
 	// Hashed/Encrypted nilai saldo akhir (lastBalance)
 	LastBalance string `json:"-" bson:"lastBalance"`
@@ -46,8 +46,8 @@ type AccountBalance struct {
 
 type UnregisterAccount struct {
 	UniqueID          string `json:"uniqueId,omitempty" bson:"uniqueId"`
-	PartnerID         string `json:"partnerId,omitempty" bson:"partnerId"`
-	MerchantID        string `json:"merchantId,omitempty" bson:"merchantId"`
+	PartnerID         string `json:"partnerId,omitempty" bson:"partnerId" validate:"required"`
+	MerchantID        string `json:"merchantId,omitempty" bson:"merchantId" validate:"required"`
 	TerminalID        string `json:"terminalId,omitempty" bson:"terminalId"`
 	Type              int    `json:"type" bson:"type"`
 	ReasonCode        int    `json:"reasonCode" bson:"reasonCode"`
@@ -55,3 +55,30 @@ type UnregisterAccount struct {
 	CreatedAt         string `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
 	UpdatedAt         string `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
 }
+
+// AccountChanges adalah field akun yang dapat diubah, field yang tidak dikirim (null) tidak akan diubah
+type AccountChanges struct {
+	TerminalName *string `json:"terminalName,omitempty"`
+	MerchantID   *string `json:"merchantId,omitempty"`
+	TerminalID   *string `json:"terminalId,omitempty"`
+}
+
+type UpdateAccountRequest struct {
+	PartnerID  string         `json:"partnerId" validate:"required"`
+	MerchantID string         `json:"merchantId" validate:"required"`
+	TerminalID string         `json:"terminalId" validate:"required"`
+	Changes    AccountChanges `json:"changes"`
+	Reason     string         `json:"reason,omitempty"`
+}
+
+// AccountAuditTrail adalah catatan perubahan data akun (nilai lama dan nilai baru)
+type AccountAuditTrail struct {
+	ID        string                 `json:"id,omitempty" bson:"_id,omitempty"`
+	AccountID string                 `json:"accountId" bson:"accountId"`
+	Action    string                 `json:"action" bson:"action"`
+	OldValues map[string]interface{} `json:"oldValues" bson:"oldValues"`
+	NewValues map[string]interface{} `json:"newValues" bson:"newValues"`
+	Reason    string                 `json:"reason,omitempty" bson:"reason"`
+	UpdatedBy string                 `json:"updatedBy,omitempty" bson:"updatedBy"`
+	CreatedAt int64                  `json:"createdAt" bson:"createdAt"`
+}
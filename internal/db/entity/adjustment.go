@@ -0,0 +1,41 @@
+package entity
+
+// BalanceAdjustment adalah koreksi saldo manual (credit/debit) yang diajukan oleh admin,
+// dan baru diterapkan ke saldo akun setelah disetujui oleh admin lain (maker-checker)
+type BalanceAdjustment struct {
+	ID         string `json:"id,omitempty" bson:"_id,omitempty"`
+	PartnerID  string `json:"partnerId" bson:"partnerId"`
+	MerchantID string `json:"merchantId" bson:"merchantId"`
+	TerminalID string `json:"terminalId" bson:"terminalId"`
+
+	// direction: credit | debit, amount selalu bernilai positif
+	Direction string `json:"direction" bson:"direction"`
+	Amount    int64  `json:"amount" bson:"amount"`
+	Reason    string `json:"reason" bson:"reason"`
+
+	// status: pending | approved | rejected | failed
+	Status  string `json:"status" bson:"status"`
+	Message string `json:"message,omitempty" bson:"message,omitempty"`
+
+	SubmittedBy string `json:"submittedBy" bson:"submittedBy"`
+	SubmittedAt int64  `json:"submittedAt" bson:"submittedAt"`
+	ReviewedBy  string `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	ReviewedAt  int64  `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+
+	// hasil transaksi adjustment setelah disetujui
+	ReceiptNumber string `json:"receiptNumber,omitempty" bson:"receiptNumber,omitempty"`
+	LastBalance   int64  `json:"lastBalance,omitempty" bson:"lastBalance,omitempty"`
+}
+
+type BalanceAdjustmentRequest struct {
+	PartnerID  string `json:"partnerId" validate:"required"`
+	MerchantID string `json:"merchantId" validate:"required"`
+	TerminalID string `json:"terminalId"`
+	Direction  string `json:"direction" validate:"required,oneof=credit debit"`
+	Amount     int64  `json:"amount" validate:"gt=0"`
+	Reason     string `json:"reason" validate:"required"`
+}
+
+type BalanceAdjustmentReview struct {
+	Reason string `json:"reason,omitempty"`
+}
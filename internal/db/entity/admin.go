@@ -0,0 +1,40 @@
+package entity
+
+// AdminAuditLog adalah catatan setiap pemanggilan endpoint admin
+type AdminAuditLog struct {
+	ID string `json:"id,omitempty" bson:"_id,omitempty"`
+
+	// subject bearer token atau key id api key pemanggil
+	Caller      string   `json:"caller" bson:"caller"`
+	CallerRoles []string `json:"callerRoles,omitempty" bson:"callerRoles"`
+
+	Action string                 `json:"action" bson:"action"`
+	Method string                 `json:"method" bson:"method"`
+	Path   string                 `json:"path" bson:"path"`
+	Params map[string]interface{} `json:"params,omitempty" bson:"params"`
+
+	// jumlah akun yang terdampak oleh action
+	AffectedCount int64 `json:"affectedCount" bson:"affectedCount"`
+
+	// hasil action
+	Success    bool   `json:"success" bson:"success"`
+	StatusCode int    `json:"statusCode" bson:"statusCode"`
+	Message    string `json:"message,omitempty" bson:"message"`
+
+	CreatedAt int64 `json:"createdAt" bson:"createdAt"`
+}
+
+type AdminAuditLogRequest struct {
+	Action  string         `json:"action,omitempty"`
+	Caller  string         `json:"caller,omitempty"`
+	Periods PeriodsRequest `json:"periods,omitempty"`
+	Page    int64          `json:"page,omitempty" validate:"min=1"`
+	Size    int64          `json:"size,omitempty" validate:"min=1,max=100"`
+}
+
+type ReactivateAccountRequest struct {
+	PartnerID  string `json:"partnerId" validate:"required"`
+	MerchantID string `json:"merchantId" validate:"required"`
+	TerminalID string `json:"terminalId"`
+	Reason     string `json:"reason,omitempty"`
+}
@@ -1,9 +1,9 @@
 package entity
 
 type InquiryBalance struct {
-	PartnerID    string `json:"partnerId" bson:"partnerId"`
-	MerchantID   string `json:"merchantId" bson:"merchantId"`
-	TerminalID   string `json:"terminalId,omitempty" bson:"terminalId"`
+	PartnerID    string `json:"partnerId" bson:"partnerId" validate:"required"`
+	MerchantID   string `json:"merchantId" bson:"merchantId" validate:"required"`
+	TerminalID   string `json:"terminalId,omitempty" bson:"terminalId" validate:"required_if=Type 1"`
 	TerminalName string `json:"terminalName,omitempty" bson:"terminalName"`
 	Type         int    `json:"-" bson:"-"`
 	LastBalance  int64  `json:"lastBalance" bson:"lastBalanceNumeric"`
@@ -0,0 +1,43 @@
+package entity
+
+type BulkRegistrationMember struct {
+	TerminalID   string `json:"terminalId"`
+	TerminalName string `json:"terminalName,omitempty"`
+}
+
+// BulkRegistrationRequest
+// adalah payload registrasi banyak member (regular account) sekaligus untuk satu partner/merchant
+type BulkRegistrationRequest struct {
+	PartnerID  string                   `json:"partnerId" validate:"required"`
+	MerchantID string                   `json:"merchantId" validate:"required"`
+	Async      bool                     `json:"async,omitempty"`
+	Members    []BulkRegistrationMember `json:"members" validate:"min=1"`
+}
+
+type BulkRegistrationResult struct {
+	JobID      string      `json:"-" bson:"jobId,omitempty"`
+	Row        int         `json:"row" bson:"row"`
+	TerminalID string      `json:"terminalId" bson:"terminalId"`
+	Success    bool        `json:"success" bson:"success"`
+	Message    string      `json:"message" bson:"message"`
+	AccountID  string      `json:"accountId,omitempty" bson:"accountId,omitempty"`
+	ErrorCode  string      `json:"errorCode,omitempty" bson:"errorCode,omitempty"`
+	Errors     interface{} `json:"errors,omitempty" bson:"errors,omitempty"`
+}
+
+// BulkRegistrationJob
+// adalah status proses registrasi bulk yang dijalankan secara async
+type BulkRegistrationJob struct {
+	ID           string                   `json:"jobId,omitempty" bson:"_id,omitempty"`
+	PartnerID    string                   `json:"partnerId" bson:"partnerId"`
+	MerchantID   string                   `json:"merchantId" bson:"merchantId"`
+	Status       string                   `json:"status" bson:"status"` // pending | processing | completed | interrupted
+	Total        int                      `json:"total" bson:"total"`
+	Processed    int                      `json:"processed" bson:"processed"`
+	SuccessCount int                      `json:"successCount" bson:"successCount"`
+	FailedCount  int                      `json:"failedCount" bson:"failedCount"`
+	Results      []BulkRegistrationResult `json:"results,omitempty" bson:"-"`    // row results disimpan terpisah di collection bulkRegistrationResults
+	Pagination   *PaginationInfo          `json:"pagination,omitempty" bson:"-"` // halaman row results yang dikembalikan
+	CreatedAt    int64                    `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt    int64                    `json:"updatedAt,omitempty" bson:"updatedAt"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+// PartnerCredential
+// adalah API credential yang diterbitkan untuk partner, api key dikirim melalui header X-API-Key
+// dengan format <keyId>.<secret>, secret hanya disimpan dalam bentuk hash (sha256).
+// SigningSecret digunakan untuk verifikasi signature (HMAC) request dan sign response
+type PartnerCredential struct {
+	ID            string `json:"id,omitempty" bson:"_id,omitempty"`
+	KeyID         string `json:"keyId" bson:"keyId"`
+	KeyHash       string `json:"-" bson:"keyHash"`
+	SigningSecret string `json:"-" bson:"signingSecret"`
+	PartnerID     string `json:"partnerId" bson:"partnerId"`
+	Description   string `json:"description,omitempty" bson:"description"`
+	Active        bool   `json:"active" bson:"active"`
+	CreatedAt     int64  `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt     int64  `json:"updatedAt,omitempty" bson:"updatedAt"`
+}
@@ -0,0 +1,52 @@
+package entity
+
+// SettlementRecord
+// adalah satu baris data topup pada settlement file (CSV) yang dikirimkan oleh partner
+type SettlementRecord struct {
+	Line             int    `json:"line"`
+	PartnerRefNumber string `json:"partnerRefNumber"`
+	PartnerTransDate string `json:"partnerTransDate"`
+	MerchantID       string `json:"merchantId,omitempty"`
+	TerminalID       string `json:"terminalId,omitempty"`
+	Amount           int64  `json:"amount"`
+}
+
+type ReconciliationItem struct {
+	PartnerRefNumber string `json:"partnerRefNumber"`
+	PartnerTransDate string `json:"partnerTransDate,omitempty"`
+	MerchantID       string `json:"merchantId,omitempty"`
+	TerminalID       string `json:"terminalId,omitempty"`
+	PartnerAmount    int64  `json:"partnerAmount"`
+	Amount           int64  `json:"amount"`
+	ReceiptNumber    string `json:"receiptNumber,omitempty"`
+	TransDate        string `json:"transDate,omitempty"`
+	Line             int    `json:"line,omitempty"`
+}
+
+type ReconciliationSummary struct {
+	TotalPartnerRecords    int   `json:"totalPartnerRecords"`
+	TotalTransactions      int   `json:"totalTransactions"`
+	Matched                int   `json:"matched"`
+	MissingOnOurSide       int   `json:"missingOnOurSide"`
+	MissingOnPartnerSide   int   `json:"missingOnPartnerSide"`
+	AmountMismatched       int   `json:"amountMismatched"`
+	DuplicateOnOurSide     int   `json:"duplicateOnOurSide"`
+	DuplicateOnPartnerSide int   `json:"duplicateOnPartnerSide"`
+	TotalPartnerAmount     int64 `json:"totalPartnerAmount"`
+	TotalAmount            int64 `json:"totalAmount"`
+}
+
+type ReconciliationReport struct {
+	PartnerID            string                `json:"partnerId"`
+	PeriodStart          string                `json:"periodStart"` // YYYYMMDD
+	PeriodEnd            string                `json:"periodEnd"`   // YYYYMMDD
+	Summary              ReconciliationSummary `json:"summary"`
+	Matched              []ReconciliationItem  `json:"matched"`
+	MissingOnOurSide     []ReconciliationItem  `json:"missingOnOurSide"`
+	MissingOnPartnerSide []ReconciliationItem  `json:"missingOnPartnerSide"`
+	AmountMismatched     []ReconciliationItem  `json:"amountMismatched"`
+	// transaksi dengan partnerRefNumber yang sama lebih dari satu kali (contoh: topup diproses dua kali)
+	DuplicateOnOurSide []ReconciliationItem `json:"duplicateOnOurSide"`
+	// baris settlement file dengan partnerRefNumber yang sama lebih dari satu kali
+	DuplicateOnPartnerSide []ReconciliationItem `json:"duplicateOnPartnerSide"`
+}
@@ -11,10 +11,10 @@ type PeriodsRequest struct {
 
 type PaginatedAccountRequest struct {
 	PartnerID  string         `json:"partnerId,omitempty"`
-	MerchantID string         `json:"merchantID,omitempty"`
-	Type       int            `json:"type,omitempty"`
-	Status     string         `json:"status,omitempty"` // { all | active | deactivated }
+	MerchantID string         `json:"merchantId,omitempty"`
+	Type       int            `json:"type,omitempty" validate:"omitempty,oneof=1 2"`
+	Status     string         `json:"status,omitempty" validate:"omitempty,oneof=all active deactivated"` // { all | active | deactivated }
 	Periods    PeriodsRequest `json:"periods,omitempty"`
-	Page       int64          `json:"page,omitempty"`
-	Size       int64          `json:"size,omitempty"`
+	Page       int64          `json:"page,omitempty" validate:"omitempty,min=1"`
+	Size       int64          `json:"size,omitempty" validate:"omitempty,min=1,max=100"`
 }
@@ -1,10 +1,11 @@
 package entity
 
 type Responses struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Total   int         `json:"total,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	ErrorCode string      `json:"errorCode,omitempty"`
+	Total     int         `json:"total,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
 }
 
 // result total field section
@@ -37,9 +38,10 @@ type PaginatedDetailResponse struct {
 }
 
 type PaginatedResponse struct {
-	Success bool                    `json:"success"`
-	Message string                  `json:"message"`
-	Data    PaginatedDetailResponse `json:"data,omitempty"`
+	Success   bool                    `json:"success"`
+	Message   string                  `json:"message"`
+	ErrorCode string                  `json:"errorCode,omitempty"`
+	Data      PaginatedDetailResponse `json:"data,omitempty"`
 }
 
 type PaginatedResponseMemberDetails struct {
@@ -50,7 +52,8 @@ type PaginatedResponseMemberDetails struct {
 }
 
 type PaginatedResponseMembers struct {
-	Success bool                            `json:"success"`
-	Message string                          `json:"message"`
-	Data    *PaginatedResponseMemberDetails `json:"data,omitempty"`
+	Success   bool                            `json:"success"`
+	Message   string                          `json:"message"`
+	ErrorCode string                          `json:"errorCode,omitempty"`
+	Data      *PaginatedResponseMemberDetails `json:"data,omitempty"`
 }
@@ -0,0 +1,76 @@
+package entity
+
+// BalanceMovement
+// adalah catatan setiap perubahan saldo (credit/debit) pada satu akun,
+// digunakan sebagai sumber data statement dan daily snapshot
+type BalanceMovement struct {
+	ID               string `json:"-" bson:"_id,omitempty"`
+	PartnerID        string `json:"partnerId" bson:"partnerId"`
+	MerchantID       string `json:"merchantId" bson:"merchantId"`
+	TerminalID       string `json:"terminalId,omitempty" bson:"terminalId"`
+	TransType        int    `json:"transType" bson:"transType"`
+	TransDate        string `json:"transDate" bson:"transDate"`               // YYYYMMDDhhmmss
+	TransDateNumeric int64  `json:"transDateNumeric" bson:"transDateNumeric"` // unix time millis
+	ReferenceNo      string `json:"referenceNo,omitempty" bson:"referenceNo"`
+	ReceiptNumber    string `json:"receiptNumber,omitempty" bson:"receiptNumber"`
+	PartnerRefNumber string `json:"partnerRefNumber,omitempty" bson:"partnerRefNumber"`
+	PartnerTransDate string `json:"partnerTransDate,omitempty" bson:"partnerTransDate"`
+	Credit           int64  `json:"credit" bson:"credit"`
+	Debit            int64  `json:"debit" bson:"debit"`
+	BalanceAfter     int64  `json:"balance" bson:"balanceAfter"`
+	CreatedAt        int64  `json:"-" bson:"createdAt"`
+}
+
+// BalanceSnapshot
+// adalah saldo penutupan (closing balance) harian untuk setiap akun
+type BalanceSnapshot struct {
+	ID             string `json:"id,omitempty" bson:"_id,omitempty"`
+	AccountID      string `json:"accountId" bson:"accountId"`
+	PartnerID      string `json:"partnerId" bson:"partnerId"`
+	MerchantID     string `json:"merchantId" bson:"merchantId"`
+	TerminalID     string `json:"terminalId,omitempty" bson:"terminalId"`
+	Type           int    `json:"type" bson:"type"`
+	SnapshotDate   string `json:"snapshotDate" bson:"snapshotDate"` // YYYYMMDD
+	ClosingBalance int64  `json:"closingBalance" bson:"closingBalance"`
+	CreatedAt      int64  `json:"createdAt,omitempty" bson:"createdAt"`
+}
+
+// SnapshotRun
+// adalah status daily snapshot terjadwal untuk setiap tanggal, hanya satu worker yang dapat mengambil snapshot pada tanggal yang sama
+type SnapshotRun struct {
+	SnapshotDate string `json:"snapshotDate" bson:"_id"` // YYYYMMDD
+	// processing | completed
+	Status string `json:"status" bson:"status"`
+	// snapshot sedang diambil oleh worker sampai waktu ini (unix time millis)
+	LeaseExpiredAt int64 `json:"-" bson:"leaseExpiredAt"`
+	Total          int   `json:"total" bson:"total"`
+	StartedAt      int64 `json:"startedAt" bson:"startedAt"`
+	FinishedAt     int64 `json:"finishedAt,omitempty" bson:"finishedAt,omitempty"`
+}
+
+type SnapshotRequest struct {
+	Date string `json:"date,omitempty"` // YYYYMMDD, default: yesterday
+}
+
+type StatementRequest struct {
+	PartnerID  string         `json:"partnerId" validate:"required"`
+	MerchantID string         `json:"merchantId" validate:"required"`
+	TerminalID string         `json:"terminalId,omitempty" validate:"required_if=Type 1"`
+	Type       int            `json:"-"`
+	Periods    PeriodsRequest `json:"periods"`
+}
+
+type AccountStatement struct {
+	AccountID      string            `json:"accountId"`
+	PartnerID      string            `json:"partnerId"`
+	MerchantID     string            `json:"merchantId"`
+	TerminalID     string            `json:"terminalId,omitempty"`
+	TerminalName   string            `json:"terminalName,omitempty"`
+	PeriodStart    string            `json:"periodStart"` // YYYYMMDD
+	PeriodEnd      string            `json:"periodEnd"`   // YYYYMMDD
+	OpeningBalance int64             `json:"openingBalance"`
+	TotalCredit    int64             `json:"totalCredit"`
+	TotalDebit     int64             `json:"totalDebit"`
+	ClosingBalance int64             `json:"closingBalance"`
+	Movements      []BalanceMovement `json:"movements"`
+}
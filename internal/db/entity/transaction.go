@@ -4,7 +4,7 @@ type TransactionItem struct {
 	ID     string `json:"id,omitempty" bson:"_id,omitempty"`
 	Code   string `json:"code,omitempty" bson:"code"`
 	Name   string `json:"name" bson:"name"`
-	Amount int64  `json:"amount" bson:"amount"`
+	Amount int64  `json:"amount" bson:"amount" validate:"gte=0"`
 	Price  int64  `json:"price,omitempty" bson:"price"`
 	Qty    int    `json:"qty,omitempty" bson:"qty"`
 }
@@ -18,15 +18,16 @@ type BalanceTransaction struct {
 	LastBalance          int64             `json:"lastBalance,omitempty" bson:"lastBalance"`
 	LastBalanceEncrypted string            `json:"-" bson:"-"`
 	Status               string            `json:"status,omitempty" bson:"status"`
-	TransType            int               `json:"transType,omitempty" bson:"transType"` // (1) TopUp | (2) Payment | (3) Distribution
+	ErrorCode            string            `json:"errorCode,omitempty" bson:"errorCode,omitempty"`
+	TransType            int               `json:"transType,omitempty" bson:"transType" validate:"required,oneof=1 2 3"` // (1) TopUp | (2) Payment | (3) Distribution | (4) Adjustment
 	PartnerTransDate     string            `json:"partnerTransDate" bson:"partnerTransDate"`
 	PartnerRefNumber     string            `json:"partnerRefNumber" bson:"partnerRefNumber"`
-	PartnerID            string            `json:"partnerId" bson:"partnerId"`
-	MerchantID           string            `json:"merchantId" bson:"merchantId"`
+	PartnerID            string            `json:"partnerId" bson:"partnerId" validate:"required"`
+	MerchantID           string            `json:"merchantId" bson:"merchantId" validate:"required"`
 	TerminalID           string            `json:"terminalId" bson:"terminalId"`
 	TerminalName         string            `json:"terminalName" bson:"terminalName"`
-	TotalAmount          int64             `json:"totalAmount" bson:"totalAmount"`
-	Items                []TransactionItem `json:"items" bson:"items"`
+	TotalAmount          int64             `json:"totalAmount" bson:"totalAmount" validate:"required_unless=TransType 3,gte=0"` // adjustment (not validated): positive (credit) or negative (debit)
+	Items                []TransactionItem `json:"items" bson:"items" validate:"dive"`
 	CreatedAt            int64             `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
 	UpdatedAt            int64             `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
 	RequestDetail        RequestDetail     `json:"requestDetail" bson:"requestDetail"`
@@ -43,3 +44,43 @@ type RequestDetail struct {
 	Origin    string `json:"origin" bson:"origin"`
 	Timestamp string `json:"timestamp,omitempty" bson:"timestamp"`
 }
+
+// TransactionRequest adalah catatan transaksi yang diterima melalui REST API,
+// digunakan agar request dengan partnerRefNumber yang sama hanya diproses satu kali (idempotent)
+type TransactionRequest struct {
+	ID               string `json:"id,omitempty" bson:"_id,omitempty"`
+	PartnerID        string `json:"partnerId" bson:"partnerId"`
+	PartnerRefNumber string `json:"partnerRefNumber" bson:"partnerRefNumber"`
+	TransType        int    `json:"transType" bson:"transType"`
+
+	// hash dari merchantId, terminalId dan totalAmount request
+	RequestHash string `json:"-" bson:"requestHash"`
+
+	// status: processing | completed
+	Status string `json:"status" bson:"status"`
+	// request processing yang melewati leaseExpiredAt (unix time millis) dianggap terhenti dan dapat diproses ulang
+	LeaseExpiredAt int64 `json:"-" bson:"leaseExpiredAt,omitempty"`
+
+	Transaction *BalanceTransaction `json:"transaction,omitempty" bson:"transaction,omitempty"`
+	CreatedAt   int64               `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   int64               `json:"updatedAt" bson:"updatedAt"`
+}
+
+// DistributionCheckpoint adalah progres balance distribution yang dihentikan saat service shutdown,
+// berisi member yang belum menerima saldo sehingga distribusi dapat dilanjutkan tanpa mengirim ulang ke member lain
+type DistributionCheckpoint struct {
+	ID               string `json:"id,omitempty" bson:"_id,omitempty"`
+	PartnerID        string `json:"partnerId" bson:"partnerId"`
+	MerchantID       string `json:"merchantId" bson:"merchantId"`
+	PartnerRefNumber string `json:"partnerRefNumber" bson:"partnerRefNumber"`
+	ReferenceNo      string `json:"referenceNo" bson:"referenceNo"`
+	PartnerTransDate string `json:"partnerTransDate" bson:"partnerTransDate"`
+	// amount untuk setiap member
+	Amount int64 `json:"amount" bson:"amount"`
+
+	TotalMembers     int      `json:"totalMembers" bson:"totalMembers"`
+	RemainingMembers []string `json:"remainingMembers" bson:"remainingMembers"`
+	// checkpoint sedang dilanjutkan oleh worker sampai waktu ini (unix time millis), 0 jika belum dilanjutkan
+	LeaseExpiredAt int64 `json:"-" bson:"leaseExpiredAt"`
+	CreatedAt      int64 `json:"createdAt" bson:"createdAt"`
+}
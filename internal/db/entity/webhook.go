@@ -0,0 +1,41 @@
+package entity
+
+// PartnerWebhook adalah konfigurasi endpoint callback hasil transaksi milik partner
+type PartnerWebhook struct {
+	ID        string `json:"id,omitempty" bson:"_id,omitempty"`
+	PartnerID string `json:"partnerId" bson:"partnerId"`
+	URL       string `json:"url" bson:"url"`
+
+	// secret untuk sign payload callback (HMAC-SHA256)
+	Secret    string `json:"secret,omitempty" bson:"secret"`
+	Active    bool   `json:"active" bson:"active"`
+	CreatedAt int64  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt" bson:"updatedAt"`
+}
+
+type PartnerWebhookRequest struct {
+	PartnerID string `json:"partnerId" validate:"required"`
+	URL       string `json:"url" validate:"required"`
+	Active    *bool  `json:"active,omitempty"`
+	// generate new signing secret
+	RotateSecret bool `json:"rotateSecret,omitempty"`
+}
+
+// WebhookDelivery adalah pengiriman callback satu hasil transaksi ke endpoint partner
+type WebhookDelivery struct {
+	ID        string `json:"id,omitempty" bson:"_id,omitempty"`
+	PartnerID string `json:"partnerId" bson:"partnerId"`
+	URL       string `json:"url" bson:"url"`
+
+	Payload BalanceTransaction `json:"payload" bson:"payload"`
+
+	// status: 01 (pending) | 00 (delivered) | 07 (callback failed)
+	Status         string `json:"status" bson:"status"`
+	Attempts       int    `json:"attempts" bson:"attempts"`
+	LastStatusCode int    `json:"lastStatusCode,omitempty" bson:"lastStatusCode"`
+	LastError      string `json:"lastError,omitempty" bson:"lastError"`
+	NextAttemptAt  int64  `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt"`
+	DeliveredAt    int64  `json:"deliveredAt,omitempty" bson:"deliveredAt"`
+	CreatedAt      int64  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      int64  `json:"updatedAt" bson:"updatedAt"`
+}
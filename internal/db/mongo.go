@@ -3,17 +3,34 @@ package db
 import (
 	"context"
 	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/metrics"
 	"github.com/dw-account-service/internal/utilities"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 	"time"
 )
 
 type MongoCollection struct {
-	Account           *mongo.Collection
-	UnregisterAccount *mongo.Collection
-	BalanceTopup      *mongo.Collection
+	Account                *mongo.Collection
+	UnregisterAccount      *mongo.Collection
+	BalanceTopup           *mongo.Collection
+	BalanceMovement        *mongo.Collection
+	BalanceSnapshot        *mongo.Collection
+	SnapshotRun            *mongo.Collection
+	BulkRegistration       *mongo.Collection
+	BulkRegistrationResult *mongo.Collection
+	AccountAuditTrail      *mongo.Collection
+	PartnerCredential      *mongo.Collection
+	AdminAuditLog          *mongo.Collection
+	BalanceAdjustment      *mongo.Collection
+	TransactionRequest     *mongo.Collection
+	PartnerWebhook         *mongo.Collection
+	WebhookDelivery        *mongo.Collection
+	DistributionCheckpoint *mongo.Collection
+	RequestNonce           *mongo.Collection
 }
 
 type MongoInstance struct {
@@ -23,16 +40,58 @@ type MongoInstance struct {
 }
 
 const (
-	AccountCollection           = "accountBalances"
-	UnregisterAccountCollection = "accountDeactivated"
-	BalanceTopupCollection      = "balanceTopup"
+	AccountCollection                = "accountBalances"
+	UnregisterAccountCollection      = "accountDeactivated"
+	BalanceTopupCollection           = "balanceTopup"
+	BalanceMovementCollection        = "balanceMovements"
+	BalanceSnapshotCollection        = "balanceSnapshots"
+	SnapshotRunCollection            = "balanceSnapshotRuns"
+	BulkRegistrationCollection       = "bulkRegistrationJobs"
+	BulkRegistrationResultCollection = "bulkRegistrationResults"
+	AccountAuditTrailCollection      = "accountAuditTrails"
+	PartnerCredentialCollection      = "partnerCredentials"
+	AdminAuditLogCollection          = "adminAuditLogs"
+	BalanceAdjustmentCollection      = "balanceAdjustments"
+	TransactionRequestCollection     = "transactionRequests"
+	PartnerWebhookCollection         = "partnerWebhooks"
+	WebhookDeliveryCollection        = "webhookDeliveries"
+	DistributionCheckpointCollection = "distributionCheckpoints"
+	RequestNonceCollection           = "requestNonces"
 )
 
 var Mongo MongoInstance
 
+// combineMonitors returns command monitor that notify every monitor, client only accept single monitor
+func combineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
+
 func (i *MongoInstance) Connect() error {
 	client, err := mongo.NewClient(options.Client().ApplyURI(configs.MainConfig.Database.Mongo.Uri).
-		SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1)))
+		SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1)).
+		SetMonitor(combineMonitors(metrics.CommandMonitor(), otelmongo.NewMonitor())))
 
 	if err != nil {
 		return err
@@ -56,16 +115,91 @@ func (i *MongoInstance) Connect() error {
 		Client: client,
 		DB:     db,
 		Collection: MongoCollection{
-			Account:           db.Collection(AccountCollection),
-			UnregisterAccount: db.Collection(UnregisterAccountCollection),
-			BalanceTopup:      db.Collection(BalanceTopupCollection),
+			Account:                db.Collection(AccountCollection),
+			UnregisterAccount:      db.Collection(UnregisterAccountCollection),
+			BalanceTopup:           db.Collection(BalanceTopupCollection),
+			BalanceMovement:        db.Collection(BalanceMovementCollection),
+			BalanceSnapshot:        db.Collection(BalanceSnapshotCollection),
+			SnapshotRun:            db.Collection(SnapshotRunCollection),
+			BulkRegistration:       db.Collection(BulkRegistrationCollection),
+			BulkRegistrationResult: db.Collection(BulkRegistrationResultCollection),
+			AccountAuditTrail:      db.Collection(AccountAuditTrailCollection),
+			PartnerCredential:      db.Collection(PartnerCredentialCollection),
+			AdminAuditLog:          db.Collection(AdminAuditLogCollection),
+			BalanceAdjustment:      db.Collection(BalanceAdjustmentCollection),
+			TransactionRequest:     db.Collection(TransactionRequestCollection),
+			PartnerWebhook:         db.Collection(PartnerWebhookCollection),
+			WebhookDelivery:        db.Collection(WebhookDeliveryCollection),
+			DistributionCheckpoint: db.Collection(DistributionCheckpointCollection),
+			RequestNonce:           db.Collection(RequestNonceCollection),
 		},
 	}
 
-	utilities.Log.Println("| database >> connected")
+	if err = i.createIndexes(); err != nil {
+		return err
+	}
+
+	utilities.Component("database").Info().Msg("database connected")
 	return nil
 }
 
+// createIndexes create indexes required by the service, existing index is left as is
+func (i *MongoInstance) createIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// partner ref number of rest api transaction can only be processed once
+	_, err := Mongo.Collection.TransactionRequest.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"partnerId", 1}, {"partnerRefNumber", 1}, {"transType", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// single webhook endpoint per partner
+	_, err = Mongo.Collection.PartnerWebhook.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"partnerId", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// statement movements and balance at given time are queried per account by transaction date
+	_, err = Mongo.Collection.BalanceMovement.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"partnerId", 1}, {"merchantId", 1}, {"terminalId", 1}, {"transDateNumeric", 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// single daily snapshot per account, snapshot can be taken by the scheduler and the admin endpoint at the same time
+	_, err = Mongo.Collection.BalanceSnapshot.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"accountId", 1}, {"snapshotDate", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// row results of bulk registration job are paginated by row number
+	_, err = Mongo.Collection.BulkRegistrationResult.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{"jobId", 1}, {"row", 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// used request nonce is removed after its expired
+	_, err = Mongo.Collection.RequestNonce.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"expiredAt", 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
 func (i *MongoInstance) Disconnect() error {
 	if Mongo.Client == nil {
 		return nil
@@ -73,7 +207,7 @@ func (i *MongoInstance) Disconnect() error {
 
 	err := Mongo.Client.Disconnect(context.TODO())
 	if err != nil {
-		utilities.Log.Println("error on closing mongodb connection: ", err.Error())
+		utilities.Component("database").Error().Err(err).Msg("failed to close mongodb connection")
 		return err
 	}
 	return nil
@@ -2,13 +2,16 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db"
 	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
-	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
 	"math"
 	"time"
 )
@@ -17,14 +20,17 @@ type AccountRepository struct {
 	Entity *entity.AccountBalance
 }
 
+// ErrEmptyPage is returned by paginated query when requested page doesn't have any document
+var ErrEmptyPage = apperror.New(apperror.CodeNotFound, "empty results or last pages has been reached")
+
 func NewAccountRepository() AccountRepository {
 	return AccountRepository{Entity: new(entity.AccountBalance)}
 }
 
-func (a *AccountRepository) Create() (interface{}, error) {
+func (a *AccountRepository) Create(ctx context.Context) (_ interface{}, err error) {
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	result, err := db.Mongo.Collection.Account.InsertOne(ctx, a.Entity)
 	if err != nil {
@@ -35,13 +41,13 @@ func (a *AccountRepository) Create() (interface{}, error) {
 }
 
 // FindByID : id args accept interface{} or primitive.ObjectID make sure to convert it first
-func (a *AccountRepository) FindByID(id interface{}) (*entity.AccountBalance, error) {
+func (a *AccountRepository) FindByID(ctx context.Context, id interface{}) (_ *entity.AccountBalance, err error) {
 	filter := bson.D{{"_id", id}}
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.FindByID", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	var account = new(entity.AccountBalance)
-	err := db.Mongo.Collection.Account.FindOne(ctx, filter).Decode(account)
+	err = db.Mongo.Collection.Account.FindOne(ctx, filter).Decode(account)
 	if err != nil {
 		return nil, err
 	}
@@ -49,13 +55,13 @@ func (a *AccountRepository) FindByID(id interface{}) (*entity.AccountBalance, er
 	return account, nil
 }
 
-func (a *AccountRepository) FindOne() (*entity.AccountBalance, error) {
+func (a *AccountRepository) FindOne(ctx context.Context) (_ *entity.AccountBalance, err error) {
 	var account entity.AccountBalance
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.FindOne", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
-	err := db.Mongo.Collection.Account.FindOne(ctx, GetDefaultAccountFilter(a.Entity)).Decode(&account)
+	err = db.Mongo.Collection.Account.FindOne(ctx, GetDefaultAccountFilter(a.Entity)).Decode(&account)
 	if err != nil {
 		return nil, err
 	}
@@ -77,17 +83,21 @@ return:
 	TotalPages 		int,
 	err 			error
 */
-func (a *AccountRepository) FindAllPaginated(request *entity.PaginatedAccountRequest) (interface{}, int64, int64, error) {
+func (a *AccountRepository) FindAllPaginated(ctx context.Context, request *entity.PaginatedAccountRequest) (_ interface{}, _ int64, _ int64, err error) {
 	filter := GetDefaultAccountStatusFilter(request.Status)
 
+	if request.PartnerID != "" {
+		filter = append(filter, bson.D{{"partnerId", request.PartnerID}}...)
+	}
+
 	if request.Type > 0 {
 		filter = append(filter, bson.D{{"type", request.Type}}...)
 	}
 
 	skipValue := (request.Page - 1) * request.Size
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.FindAllPaginated", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	cursor, err := db.Mongo.Collection.Account.Find(
 		ctx,
@@ -109,14 +119,14 @@ func (a *AccountRepository) FindAllPaginated(request *entity.PaginatedAccountReq
 	}
 
 	if len(accounts) == 0 {
-		return nil, 0, 0, errors.New("empty results or last pages has been reached")
+		return nil, 0, 0, ErrEmptyPage
 	}
 
 	totalPages := math.Ceil(float64(totalDocs) / float64(request.Size))
 	return &accounts, totalDocs, int64(totalPages), nil
 }
 
-func (a *AccountRepository) DeactivateAccount(payload *entity.UnregisterAccount) error {
+func (a *AccountRepository) DeactivateAccount(ctx context.Context, payload *entity.UnregisterAccount) (err error) {
 
 	// update field
 	update := bson.D{
@@ -126,8 +136,8 @@ func (a *AccountRepository) DeactivateAccount(payload *entity.UnregisterAccount)
 		}},
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.DeactivateAccount", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	result, err := db.Mongo.Collection.Account.UpdateOne(
 		ctx,
@@ -142,16 +152,16 @@ func (a *AccountRepository) DeactivateAccount(payload *entity.UnregisterAccount)
 	}
 
 	if result.ModifiedCount == 0 {
-		return errors.New("update failed, cannot find account with current uniqueId")
+		return apperror.New(apperror.CodeAccountNotFound, "update failed, cannot find account with current uniqueId")
 	}
 
 	return nil
 }
 
-func (a *AccountRepository) InsertDeactivatedAccount(account *entity.UnregisterAccount) (interface{}, error) {
+func (a *AccountRepository) InsertDeactivatedAccount(ctx context.Context, account *entity.UnregisterAccount) (_ interface{}, err error) {
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.InsertDeactivatedAccount", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	result, err := db.Mongo.Collection.UnregisterAccount.InsertOne(ctx, account)
 
@@ -163,28 +173,153 @@ func (a *AccountRepository) InsertDeactivatedAccount(account *entity.UnregisterA
 
 }
 
-func (a *AccountRepository) RemoveDeactivatedAccount(acc *entity.UnregisterAccount) (int, error) {
-	filter := bson.D{{"uniqueId", acc.UniqueID}}
+// Reactivate activate the deactivated account and remove its deactivation record in a single transaction.
+// deactivation record is matched by the account partnerId, merchantId, terminalId and type,
+// account without deactivation record is not reactivated
+func (a *AccountRepository) Reactivate(ctx context.Context, account *entity.AccountBalance) (_ *entity.AccountBalance, err error) {
+	objectID, err := primitive.ObjectIDFromHex(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	deactivated := bson.D{
+		{"partnerId", account.PartnerID},
+		{"merchantId", account.MerchantID},
+		{"terminalId", account.TerminalID},
+		{"type", account.Type},
+	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.Reactivate", 3*time.Second)
+	defer func() { finish(err) }()
+
+	updatedAccount := new(entity.AccountBalance)
+	err = inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		removed, err := db.Mongo.Collection.UnregisterAccount.DeleteOne(ctx, deactivated)
+		if err != nil {
+			return err
+		}
+
+		if removed.DeletedCount == 0 {
+			return apperror.New(apperror.CodeNotFound, "reactivation failed, cannot find deactivation record of the account")
+		}
+
+		result, err := db.Mongo.Collection.Account.UpdateOne(ctx,
+			bson.D{{"_id", objectID}, {"active", false}},
+			bson.D{{"$set", bson.D{{"active", true}, {"updatedAt", time.Now().UnixMilli()}}}},
+		)
+		if err != nil {
+			return err
+		}
+
+		if result.MatchedCount == 0 {
+			return apperror.New(apperror.CodeConflict, "reactivation failed, account is not in deactivated status")
+		}
+
+		return db.Mongo.Collection.Account.FindOne(ctx, bson.D{{"_id", objectID}}).Decode(updatedAccount)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := db.Mongo.Collection.UnregisterAccount.DeleteOne(ctx, filter)
+	return updatedAccount, nil
+}
 
+// UpdateIdentity update profile fields of the account and insert its audit trail in a single transaction.
+// when merchantId/terminalId is changed, balance movements, snapshots and deactivation record of the account are moved
+// to the new merchantId/terminalId, so statement and opening balance keep its history and the account can be reactivated
+func (a *AccountRepository) UpdateIdentity(ctx context.Context, account *entity.AccountBalance, fields bson.D, audit *entity.AccountAuditTrail) (_ *entity.AccountBalance, err error) {
+	objectID, err := primitive.ObjectIDFromHex(account.ID)
 	if err != nil {
-		return fiber.StatusInternalServerError, err
+		return nil, err
 	}
 
-	if result.DeletedCount > 0 {
-		return fiber.StatusNoContent, nil
+	moved := *account
+	for _, field := range fields {
+		switch field.Key {
+		case "merchantId":
+			moved.MerchantID, _ = field.Value.(string)
+		case "terminalId":
+			moved.TerminalID, _ = field.Value.(string)
+		case "uniqueId":
+			moved.UniqueID, _ = field.Value.(string)
+		}
 	}
 
-	return fiber.StatusInternalServerError, errors.New("remove deactivated account failed, no document found")
+	fields = append(fields, bson.D{{"updatedAt", time.Now().UnixMilli()}}...)
+
+	ctx, finish := operation(ctx, "AccountRepository.UpdateIdentity", 30*time.Second)
+	defer func() { finish(err) }()
+
+	updatedAccount := new(entity.AccountBalance)
+	err = inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		result, err := db.Mongo.Collection.Account.UpdateOne(ctx, bson.D{{"_id", objectID}}, bson.D{{"$set", fields}})
+		if err != nil {
+			return err
+		}
+
+		if result.MatchedCount == 0 {
+			return apperror.New(apperror.CodeAccountNotFound, "update failed, cannot find account with current id")
+		}
+
+		if moved.MerchantID != account.MerchantID || moved.TerminalID != account.TerminalID {
+			identity := bson.D{{"$set", bson.D{
+				{"merchantId", moved.MerchantID},
+				{"terminalId", moved.TerminalID},
+			}}}
+
+			if _, err = db.Mongo.Collection.BalanceMovement.UpdateMany(ctx, getMovementAccountFilter(account), identity); err != nil {
+				return err
+			}
+
+			if _, err = db.Mongo.Collection.BalanceSnapshot.UpdateMany(ctx, bson.D{{"accountId", account.ID}}, identity); err != nil {
+				return err
+			}
+
+			// deactivation record is matched by account identity, see Reactivate
+			deactivated := bson.D{
+				{"partnerId", account.PartnerID},
+				{"merchantId", account.MerchantID},
+				{"terminalId", account.TerminalID},
+				{"type", account.Type},
+			}
+			deactivatedIdentity := bson.D{{"$set", bson.D{
+				{"merchantId", moved.MerchantID},
+				{"terminalId", moved.TerminalID},
+				{"uniqueId", moved.UniqueID},
+			}}}
+			if _, err = db.Mongo.Collection.UnregisterAccount.UpdateMany(ctx, deactivated, deactivatedIdentity); err != nil {
+				return err
+			}
+		}
+
+		if _, err = db.Mongo.Collection.AccountAuditTrail.InsertOne(ctx, audit); err != nil {
+			return err
+		}
+
+		return db.Mongo.Collection.Account.FindOne(ctx, bson.D{{"_id", objectID}}).Decode(updatedAccount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedAccount, nil
+}
+
+func (a *AccountRepository) InsertAuditTrail(ctx context.Context, audit *entity.AccountAuditTrail) (_ interface{}, err error) {
+	ctx, finish := operation(ctx, "AccountRepository.InsertAuditTrail", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.AccountAuditTrail.InsertOne(ctx, audit)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.InsertedID, nil
 }
 
 // ----------------- MERCHANTS ----------------
 
-func (a *AccountRepository) FindMembersPaginated(request *entity.PaginatedAccountRequest, isPeriod bool) (interface{}, int64, int64, error) {
+func (a *AccountRepository) FindMembersPaginated(ctx context.Context, request *entity.PaginatedAccountRequest, isPeriod bool) (_ interface{}, _ int64, _ int64, err error) {
 	filter := GetDefaultAccountStatusFilter(request.Status)
 
 	filter = append(filter, bson.D{
@@ -205,8 +340,8 @@ func (a *AccountRepository) FindMembersPaginated(request *entity.PaginatedAccoun
 
 	skipValue := (request.Page - 1) * request.Size
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.FindMembersPaginated", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	cursor, err := db.Mongo.Collection.Account.Find(
 		ctx,
@@ -228,14 +363,14 @@ func (a *AccountRepository) FindMembersPaginated(request *entity.PaginatedAccoun
 	}
 
 	if accounts == nil {
-		return nil, 0, 0, errors.New("empty results or last pages has been reached")
+		return nil, 0, 0, ErrEmptyPage
 	}
 
 	totalPages := math.Ceil(float64(totalDocs) / float64(request.Size))
 	return &accounts, totalDocs, int64(totalPages), nil
 }
 
-func (a *AccountRepository) FindMembers(request *entity.PaginatedAccountRequest) ([]entity.AccountBalance, error) {
+func (a *AccountRepository) FindMembers(ctx context.Context, request *entity.PaginatedAccountRequest) (_ []entity.AccountBalance, err error) {
 	filter := GetDefaultAccountStatusFilter(request.Status)
 
 	filter = append(filter, bson.D{
@@ -244,8 +379,8 @@ func (a *AccountRepository) FindMembers(request *entity.PaginatedAccountRequest)
 		{"type", request.Type},
 	}...)
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.FindMembers", 3*time.Second)
+	defer func() { finish(err) }()
 
 	cursor, err := db.Mongo.Collection.Account.Find(ctx, filter)
 
@@ -261,7 +396,7 @@ func (a *AccountRepository) FindMembers(request *entity.PaginatedAccountRequest)
 	return accounts, nil
 }
 
-func (a *AccountRepository) CountMembers() (int64, error) {
+func (a *AccountRepository) CountMembers(ctx context.Context) (_ int64, err error) {
 	filter := bson.D{
 		{"partnerId", a.Entity.PartnerID},
 		{"merchantId", a.Entity.MerchantID},
@@ -269,8 +404,8 @@ func (a *AccountRepository) CountMembers() (int64, error) {
 		{"active", true},
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 1500*time.Millisecond)
-	defer cancel()
+	ctx, finish := operation(ctx, "AccountRepository.CountMembers", 1500*time.Millisecond)
+	defer func() { finish(err) }()
 
 	totalDocs, err := db.Mongo.Collection.Account.CountDocuments(ctx, filter)
 	if err != nil {
@@ -279,3 +414,28 @@ func (a *AccountRepository) CountMembers() (int64, error) {
 
 	return totalDocs, nil
 }
+
+// Iterate walk through every account matching the filter using cursor, without loading all documents into memory
+func (a *AccountRepository) Iterate(ctx context.Context, filter bson.D, fn func(account *entity.AccountBalance) error, opts ...*options.FindOptions) error {
+	ctx, span := tracing.Start(ctx, "AccountRepository.Iterate", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	cursor, err := db.Mongo.Collection.Account.Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		account := new(entity.AccountBalance)
+		if err = cursor.Decode(account); err != nil {
+			return err
+		}
+
+		if err = fn(account); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
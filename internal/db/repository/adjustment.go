@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/utilities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"math"
+	"time"
+)
+
+type AdjustmentRepository struct {
+	Entity *entity.BalanceAdjustment
+}
+
+func NewAdjustmentRepository() AdjustmentRepository {
+	return AdjustmentRepository{Entity: new(entity.BalanceAdjustment)}
+}
+
+func (r *AdjustmentRepository) Create(ctx context.Context) (_ string, err error) {
+	ctx, finish := operation(ctx, "AdjustmentRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.BalanceAdjustment.InsertOne(ctx, r.Entity)
+	if err != nil {
+		return "", err
+	}
+
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	r.Entity.ID = id
+
+	return id, nil
+}
+
+func (r *AdjustmentRepository) FindByID(ctx context.Context, id string) (_ *entity.BalanceAdjustment, err error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, finish := operation(ctx, "AdjustmentRepository.FindByID", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	adjustment := new(entity.BalanceAdjustment)
+	err = db.Mongo.Collection.BalanceAdjustment.FindOne(ctx, bson.D{{"_id", objectID}}).Decode(adjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustment, nil
+}
+
+// Review change pending adjustment status and set its reviewer, it returns mongo.ErrNoDocuments
+// when the adjustment is no longer pending, so an adjustment can only be reviewed once
+func (r *AdjustmentRepository) Review(ctx context.Context, id, status, reviewer, message string) (_ *entity.BalanceAdjustment, err error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.D{
+		{"$set", bson.D{
+			{"status", status},
+			{"reviewedBy", reviewer},
+			{"reviewedAt", time.Now().UnixMilli()},
+			{"message", message},
+		}},
+	}
+
+	ctx, finish := operation(ctx, "AdjustmentRepository.Review", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	adjustment := new(entity.BalanceAdjustment)
+	err = db.Mongo.Collection.BalanceAdjustment.FindOneAndUpdate(
+		ctx,
+		bson.D{{"_id", objectID}, {"status", utilities.AdjustmentStatusPending}},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(adjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustment, nil
+}
+
+// UpdateResult store result of approved adjustment transaction
+func (r *AdjustmentRepository) UpdateResult(ctx context.Context, adjustment *entity.BalanceAdjustment) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(adjustment.ID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{
+		{"$set", bson.D{
+			{"status", adjustment.Status},
+			{"message", adjustment.Message},
+			{"receiptNumber", adjustment.ReceiptNumber},
+			{"lastBalance", adjustment.LastBalance},
+		}},
+	}
+
+	ctx, finish := operation(ctx, "AdjustmentRepository.UpdateResult", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.BalanceAdjustment.UpdateOne(ctx, bson.D{{"_id", objectID}}, update)
+	return err
+}
+
+// FindAllPaginated returns adjustments filtered by status (optional), newest first
+func (r *AdjustmentRepository) FindAllPaginated(ctx context.Context, status string, page, size int64) (_ []entity.BalanceAdjustment, _ int64, _ int64, err error) {
+	filter := bson.D{}
+	if status != "" {
+		filter = append(filter, bson.D{{"status", status}}...)
+	}
+
+	ctx, finish := operation(ctx, "AdjustmentRepository.FindAllPaginated", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	cursor, err := db.Mongo.Collection.BalanceAdjustment.Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{"submittedAt", -1}}).
+			SetSkip((page-1)*size).
+			SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalDocs, _ := db.Mongo.Collection.BalanceAdjustment.CountDocuments(ctx, filter)
+	var adjustments []entity.BalanceAdjustment
+	if err = cursor.All(ctx, &adjustments); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if len(adjustments) == 0 {
+		return nil, 0, 0, ErrEmptyPage
+	}
+
+	totalPages := math.Ceil(float64(totalDocs) / float64(size))
+	return adjustments, totalDocs, int64(totalPages), nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"math"
+	"time"
+)
+
+type AdminAuditRepository struct {
+	Entity *entity.AdminAuditLog
+}
+
+func NewAdminAuditRepository() AdminAuditRepository {
+	return AdminAuditRepository{Entity: new(entity.AdminAuditLog)}
+}
+
+func (r *AdminAuditRepository) Create(ctx context.Context) (_ interface{}, err error) {
+	ctx, finish := operation(ctx, "AdminAuditRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.AdminAuditLog.InsertOne(ctx, r.Entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.InsertedID, nil
+}
+
+// FindAllPaginated returns audit logs filtered by action, caller and periods (when periods start date is set), newest first
+func (r *AdminAuditRepository) FindAllPaginated(ctx context.Context, request *entity.AdminAuditLogRequest) (_ []entity.AdminAuditLog, _ int64, _ int64, err error) {
+	filter := bson.D{}
+
+	if request.Action != "" {
+		filter = append(filter, bson.D{{"action", request.Action}}...)
+	}
+
+	if request.Caller != "" {
+		filter = append(filter, bson.D{{"caller", request.Caller}}...)
+	}
+
+	if !request.Periods.StartDate.IsZero() {
+		filter = append(filter,
+			bson.D{
+				{"createdAt", bson.D{
+					{"$gte", request.Periods.StartDate.UnixMilli()},
+					{"$lte", request.Periods.EndDate.UnixMilli()},
+				}},
+			}...)
+	}
+
+	skipValue := (request.Page - 1) * request.Size
+
+	ctx, finish := operation(ctx, "AdminAuditRepository.FindAllPaginated", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	cursor, err := db.Mongo.Collection.AdminAuditLog.Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{"createdAt", -1}}).
+			SetSkip(skipValue).
+			SetLimit(request.Size),
+	)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalDocs, _ := db.Mongo.Collection.AdminAuditLog.CountDocuments(ctx, filter)
+	var logs []entity.AdminAuditLog
+	if err = cursor.All(ctx, &logs); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if len(logs) == 0 {
+		return nil, 0, 0, ErrEmptyPage
+	}
+
+	totalPages := math.Ceil(float64(totalDocs) / float64(request.Size))
+	return logs, totalDocs, int64(totalPages), nil
+}
@@ -23,7 +23,7 @@ func NewBalanceRepository() BalanceRepository {
 	}
 }
 
-func (b *BalanceRepository) GetLastBalance() error {
+func (b *BalanceRepository) GetLastBalance(ctx context.Context) (err error) {
 	// filter criteria
 	filter := bson.D{
 		{"active", true},
@@ -36,10 +36,10 @@ func (b *BalanceRepository) GetLastBalance() error {
 		filter = append(filter, bson.D{{"terminalId", b.Entity.TerminalID}}...)
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+	ctx, finish := operation(ctx, "BalanceRepository.GetLastBalance", 3*time.Second)
+	defer func() { finish(err) }()
 
-	err := db.Mongo.Collection.Account.FindOne(
+	err = db.Mongo.Collection.Account.FindOne(
 		ctx,
 		filter,
 		options.FindOne().SetProjection(bson.D{
@@ -63,7 +63,7 @@ func (b *BalanceRepository) GetLastBalance() error {
 	return nil
 }
 
-func (b *BalanceRepository) MerchantInquiryBalance(inquiry entity.BalanceInquiry) (int, entity.BalanceInquiry, error) {
+func (b *BalanceRepository) MerchantInquiryBalance(ctx context.Context, inquiry entity.BalanceInquiry) (_ int, _ entity.BalanceInquiry, err error) {
 
 	// filter criteria
 	filter := bson.D{
@@ -71,11 +71,11 @@ func (b *BalanceRepository) MerchantInquiryBalance(inquiry entity.BalanceInquiry
 		{"merchantId", inquiry.MerchantID},
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+	ctx, finish := operation(ctx, "BalanceRepository.MerchantInquiryBalance", 3*time.Second)
+	defer func() { finish(err) }()
 
 	var balance entity.BalanceInquiry
-	err := db.Mongo.Collection.Account.FindOne(
+	err = db.Mongo.Collection.Account.FindOne(
 		ctx,
 		filter,
 		options.FindOne().SetProjection(bson.D{{"_id", 0}}),
@@ -93,7 +93,7 @@ func (b *BalanceRepository) MerchantInquiryBalance(inquiry entity.BalanceInquiry
 }
 
 // UpdateBalance is a function that update lastBalance field based on supplied uniqueId
-func (b *BalanceRepository) UpdateBalance(uid string, lastBalance string) (int, error) {
+func (b *BalanceRepository) UpdateBalance(ctx context.Context, uid string, lastBalance string) (_ int, err error) {
 
 	// 1. update balance on current document
 	filter := bson.D{{"uniqueId", uid}}
@@ -104,8 +104,8 @@ func (b *BalanceRepository) UpdateBalance(uid string, lastBalance string) (int,
 		}},
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+	ctx, finish := operation(ctx, "BalanceRepository.UpdateBalance", 3*time.Second)
+	defer func() { finish(err) }()
 
 	result, err := db.Mongo.Collection.Account.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -120,7 +120,7 @@ func (b *BalanceRepository) UpdateBalance(uid string, lastBalance string) (int,
 	return fiber.StatusOK, nil
 }
 
-func (b *BalanceRepository) UpdateMerchantBalance(t *entity.BalanceTopUp) (int, error) {
+func (b *BalanceRepository) UpdateMerchantBalance(ctx context.Context, t *entity.BalanceTopUp) (_ int, err error) {
 
 	// 1. update balance on current document
 	filter := bson.D{{"partnerId", t.PartnerID}, {"merchantId", t.MerchantID}}
@@ -132,8 +132,8 @@ func (b *BalanceRepository) UpdateMerchantBalance(t *entity.BalanceTopUp) (int,
 		}},
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
-	defer cancel()
+	ctx, finish := operation(ctx, "BalanceRepository.UpdateMerchantBalance", 3*time.Second)
+	defer func() { finish(err) }()
 
 	result, err := db.Mongo.Collection.Account.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type BulkRegistrationRepository struct {
+	Entity *entity.BulkRegistrationJob
+}
+
+func NewBulkRegistrationRepository() BulkRegistrationRepository {
+	return BulkRegistrationRepository{Entity: new(entity.BulkRegistrationJob)}
+}
+
+func (b *BulkRegistrationRepository) Create(ctx context.Context) (_ string, err error) {
+	ctx, finish := operation(ctx, "BulkRegistrationRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.BulkRegistration.InsertOne(ctx, b.Entity)
+	if err != nil {
+		return "", err
+	}
+
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	b.Entity.ID = id
+
+	return id, nil
+}
+
+func (b *BulkRegistrationRepository) FindByID(ctx context.Context, id string) (_ *entity.BulkRegistrationJob, err error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, finish := operation(ctx, "BulkRegistrationRepository.FindByID", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	job := new(entity.BulkRegistrationJob)
+	err = db.Mongo.Collection.BulkRegistration.FindOne(ctx, bson.D{{"_id", objectID}}).Decode(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// FindResults returns row results of the job ordered by row number, total is number of stored row results
+func (b *BulkRegistrationRepository) FindResults(ctx context.Context, jobID string, page, size int64) (_ []entity.BulkRegistrationResult, _ int64, err error) {
+	filter := bson.D{{"jobId", jobID}}
+
+	ctx, finish := operation(ctx, "BulkRegistrationRepository.FindResults", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	cursor, err := db.Mongo.Collection.BulkRegistrationResult.Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{"row", 1}}).
+			SetSkip((page-1)*size).
+			SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalDocs, _ := db.Mongo.Collection.BulkRegistrationResult.CountDocuments(ctx, filter)
+	results := make([]entity.BulkRegistrationResult, 0)
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+
+	return results, totalDocs, nil
+}
+
+// AppendResults store processed row results and update job progress of current Entity.
+// row results are stored in their own collection, so job document size does not grow with number of rows
+func (b *BulkRegistrationRepository) AppendResults(ctx context.Context, results []entity.BulkRegistrationResult) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(b.Entity.ID)
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{
+		{"$set", bson.D{
+			{"status", b.Entity.Status},
+			{"processed", b.Entity.Processed},
+			{"successCount", b.Entity.SuccessCount},
+			{"failedCount", b.Entity.FailedCount},
+			{"updatedAt", time.Now().UnixMilli()},
+		}},
+	}
+
+	docs := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		result.JobID = b.Entity.ID
+		docs = append(docs, result)
+	}
+
+	ctx, finish := operation(ctx, "BulkRegistrationRepository.AppendResults", 3*time.Second)
+	defer func() { finish(err) }()
+
+	// results and progress are updated together, so processed always match stored row results
+	err = inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		if len(docs) > 0 {
+			if _, err := db.Mongo.Collection.BulkRegistrationResult.InsertMany(ctx, docs); err != nil {
+				return err
+			}
+		}
+
+		_, err := db.Mongo.Collection.BulkRegistration.UpdateOne(ctx, bson.D{{"_id", objectID}}, update)
+		return err
+	})
+	return err
+}
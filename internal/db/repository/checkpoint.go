@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type CheckpointRepository struct {
+	Entity *entity.DistributionCheckpoint
+}
+
+func NewCheckpointRepository() CheckpointRepository {
+	return CheckpointRepository{Entity: new(entity.DistributionCheckpoint)}
+}
+
+func (r *CheckpointRepository) Create(ctx context.Context) (_ string, err error) {
+	ctx, finish := operation(ctx, "CheckpointRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.DistributionCheckpoint.InsertOne(ctx, r.Entity)
+	if err != nil {
+		return "", err
+	}
+
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	r.Entity.ID = id
+
+	return id, nil
+}
+
+// Claim pick single checkpoint which is not being resumed, and lease it by lease duration
+// so it's not resumed by other worker at the same time. it returns mongo.ErrNoDocuments when nothing is left
+func (r *CheckpointRepository) Claim(ctx context.Context, lease time.Duration) (_ *entity.DistributionCheckpoint, err error) {
+	now := time.Now()
+
+	ctx, finish := operation(ctx, "CheckpointRepository.Claim", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	checkpoint := new(entity.DistributionCheckpoint)
+	err = db.Mongo.Collection.DistributionCheckpoint.FindOneAndUpdate(
+		ctx,
+		bson.D{{"leaseExpiredAt", bson.D{{"$lte", now.UnixMilli()}}}},
+		bson.D{{"$set", bson.D{{"leaseExpiredAt", now.Add(lease).UnixMilli()}}}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{"createdAt", 1}}).
+			SetReturnDocument(options.After),
+	).Decode(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// RemoveMember remove member which has been processed from the checkpoint, and extend its lease
+func (r *CheckpointRepository) RemoveMember(ctx context.Context, id, memberID string, lease time.Duration) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, finish := operation(ctx, "CheckpointRepository.RemoveMember", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.DistributionCheckpoint.UpdateOne(
+		ctx,
+		bson.D{{"_id", objectID}},
+		bson.D{
+			{"$pull", bson.D{{"remainingMembers", memberID}}},
+			{"$set", bson.D{{"leaseExpiredAt", time.Now().Add(lease).UnixMilli()}}},
+		},
+	)
+	return err
+}
+
+// Release end the checkpoint lease, so it can be claimed again
+func (r *CheckpointRepository) Release(ctx context.Context, id string) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, finish := operation(ctx, "CheckpointRepository.Release", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.DistributionCheckpoint.UpdateOne(
+		ctx,
+		bson.D{{"_id", objectID}},
+		bson.D{{"$set", bson.D{{"leaseExpiredAt", 0}}}},
+	)
+	return err
+}
+
+// Delete remove checkpoint which every member has been processed
+func (r *CheckpointRepository) Delete(ctx context.Context, id string) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, finish := operation(ctx, "CheckpointRepository.Delete", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.DistributionCheckpoint.DeleteOne(ctx, bson.D{{"_id", objectID}})
+	return err
+}
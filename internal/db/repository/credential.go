@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+type CredentialRepository struct {
+	Entity *entity.PartnerCredential
+}
+
+func NewCredentialRepository() CredentialRepository {
+	return CredentialRepository{Entity: new(entity.PartnerCredential)}
+}
+
+func (r *CredentialRepository) Create(ctx context.Context) (_ interface{}, err error) {
+	ctx, finish := operation(ctx, "CredentialRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.PartnerCredential.InsertOne(ctx, r.Entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.InsertedID, nil
+}
+
+func (r *CredentialRepository) FindByKeyID(ctx context.Context, keyID string) (_ *entity.PartnerCredential, err error) {
+	ctx, finish := operation(ctx, "CredentialRepository.FindByKeyID", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	credential := new(entity.PartnerCredential)
+	err = db.Mongo.Collection.PartnerCredential.FindOne(ctx, bson.D{{"keyId", keyID}}).Decode(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func (r *CredentialRepository) Revoke(ctx context.Context, keyID string) (err error) {
+	update := bson.D{
+		{"$set", bson.D{
+			{"active", false},
+			{"updatedAt", time.Now().UnixMilli()},
+		}},
+	}
+
+	ctx, finish := operation(ctx, "CredentialRepository.Revoke", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.PartnerCredential.UpdateOne(ctx, bson.D{{"keyId", keyID}}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return apperror.New(apperror.CodeNotFound, "revoke failed, cannot find credential with current keyId")
+	}
+
+	return nil
+}
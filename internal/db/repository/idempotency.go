@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	TransactionRequestProcessing = "processing"
+	TransactionRequestCompleted  = "completed"
+
+	// processing request is reclaimable after its lease, e.g. when the process is stopped before its completed
+	transactionRequestLease = time.Minute
+)
+
+type TransactionRequestRepository struct {
+	Entity *entity.TransactionRequest
+}
+
+func NewTransactionRequestRepository() TransactionRequestRepository {
+	return TransactionRequestRepository{Entity: new(entity.TransactionRequest)}
+}
+
+func (t *TransactionRequestRepository) filter() bson.D {
+	return bson.D{
+		{"partnerId", t.Entity.PartnerID},
+		{"partnerRefNumber", t.Entity.PartnerRefNumber},
+		{"transType", t.Entity.TransType},
+	}
+}
+
+// Reserve insert current Entity as processing request. when the partner ref number has been used,
+// it returns false with the existing request, so the transaction is only processed once.
+// processing request of the same payload with expired lease is reclaimed, it returns true with the reclaimed request
+func (t *TransactionRequestRepository) Reserve(ctx context.Context) (_ bool, _ *entity.TransactionRequest, err error) {
+	ctx, finish := operation(ctx, "TransactionRequestRepository.Reserve", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	now := time.Now()
+	t.Entity.Status = TransactionRequestProcessing
+	t.Entity.LeaseExpiredAt = now.Add(transactionRequestLease).UnixMilli()
+	t.Entity.CreatedAt = now.UnixMilli()
+	t.Entity.UpdatedAt = t.Entity.CreatedAt
+
+	_, err = db.Mongo.Collection.TransactionRequest.InsertOne(ctx, t.Entity)
+	if err == nil {
+		return true, nil, nil
+	}
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, nil, err
+	}
+
+	existing := new(entity.TransactionRequest)
+	if err = db.Mongo.Collection.TransactionRequest.FindOne(ctx, t.filter()).Decode(existing); err != nil {
+		return false, nil, err
+	}
+
+	if existing.Status != TransactionRequestProcessing || existing.RequestHash != t.Entity.RequestHash ||
+		existing.LeaseExpiredAt > now.UnixMilli() {
+		return false, existing, nil
+	}
+
+	// only one of concurrent retries can reclaim the expired lease
+	filter := append(t.filter(), bson.D{
+		{"status", TransactionRequestProcessing},
+		{"leaseExpiredAt", existing.LeaseExpiredAt},
+	}...)
+	result, err := db.Mongo.Collection.TransactionRequest.UpdateOne(ctx, filter, bson.D{
+		{"$set", bson.D{
+			{"leaseExpiredAt", t.Entity.LeaseExpiredAt},
+			{"updatedAt", t.Entity.UpdatedAt},
+		}},
+	})
+	if err != nil {
+		return false, nil, err
+	}
+
+	if result.MatchedCount == 0 {
+		return false, existing, nil
+	}
+
+	t.Entity.CreatedAt = existing.CreatedAt
+	return true, existing, nil
+}
+
+// Complete store transaction result of current Entity
+func (t *TransactionRequestRepository) Complete(ctx context.Context, trx *entity.BalanceTransaction) (err error) {
+	ctx, finish := operation(ctx, "TransactionRequestRepository.Complete", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	t.Entity.Status = TransactionRequestCompleted
+	t.Entity.Transaction = trx
+	t.Entity.UpdatedAt = time.Now().UnixMilli()
+
+	_, err = db.Mongo.Collection.TransactionRequest.UpdateOne(ctx, t.filter(), bson.D{
+		{"$set", bson.D{
+			{"status", t.Entity.Status},
+			{"transaction", t.Entity.Transaction},
+			{"updatedAt", t.Entity.UpdatedAt},
+		}},
+	})
+	return err
+}
+
+// Release remove reservation of current Entity, so the request can be retried.
+// reservation that has been reclaimed by other request is kept
+func (t *TransactionRequestRepository) Release(ctx context.Context) (err error) {
+	ctx, finish := operation(ctx, "TransactionRequestRepository.Release", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	filter := append(t.filter(), bson.D{
+		{"status", TransactionRequestProcessing},
+		{"leaseExpiredAt", t.Entity.LeaseExpiredAt},
+	}...)
+	_, err = db.Mongo.Collection.TransactionRequest.DeleteOne(ctx, filter)
+	return err
+}
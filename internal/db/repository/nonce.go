@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// NonceRepository store used request nonce, expired nonce is removed by ttl index of expiredAt
+type NonceRepository struct{}
+
+func NewNonceRepository() NonceRepository {
+	return NonceRepository{}
+}
+
+// Use store the nonce until ttl and returns false if the nonce has been used and not yet expired.
+// expired nonce that hasn't been removed by the ttl index can be used again
+func (r *NonceRepository) Use(ctx context.Context, nonce string, ttl time.Duration) (_ bool, err error) {
+	ctx, finish := operation(ctx, "NonceRepository.Use", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	now := time.Now()
+	_, err = db.Mongo.Collection.RequestNonce.InsertOne(ctx, bson.D{
+		{"_id", nonce},
+		{"expiredAt", now.Add(ttl)},
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	result, err := db.Mongo.Collection.RequestNonce.UpdateOne(ctx,
+		bson.D{{"_id", nonce}, {"expiredAt", bson.D{{"$lte", now}}}},
+		bson.D{{"$set", bson.D{{"expiredAt", now.Add(ttl)}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
@@ -1,11 +1,63 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db"
 	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
+// operation returns context of repository operation that is cancelled after the timeout,
+// the operation is traced as child span of the context, its mongodb commands are traced as child of the span.
+// the returned end func must be called with error of the operation, so failed operation is recorded in its span
+func operation(ctx context.Context, name string, timeout time.Duration) (context.Context, func(err error)) {
+	ctx, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, func(err error) {
+		cancel()
+
+		// document not found is result of the operation, not its failure
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			err = nil
+		}
+		tracing.End(span, err)
+	}
+}
+
+// parseID returns object id of the requested entity, invalid id is a validation error instead of internal error
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, apperror.Validation("invalid id")
+	}
+
+	return objectID, nil
+}
+
+// inTransaction run fn in a mongodb transaction, writes of fn are committed together or not at all.
+// fn is retried on transient transaction errors, so it must be idempotent
+func inTransaction(ctx context.Context, fn func(ctx mongo.SessionContext) error) error {
+	session, err := db.Mongo.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
 func GetDefaultAccountFilter(account *entity.AccountBalance) bson.D {
 	filter := bson.D{
 		{"partnerId", account.PartnerID},
@@ -36,3 +88,32 @@ func GetDefaultAccountStatusFilter(status string) bson.D {
 
 	return filter
 }
+
+// GetAccountListFilter build account filter from PaginatedAccountRequest (status, partnerId, merchantId, type and periods)
+func GetAccountListFilter(request *entity.PaginatedAccountRequest, isPeriod bool) bson.D {
+	filter := GetDefaultAccountStatusFilter(request.Status)
+
+	if request.PartnerID != "" {
+		filter = append(filter, bson.D{{"partnerId", request.PartnerID}}...)
+	}
+
+	if request.MerchantID != "" {
+		filter = append(filter, bson.D{{"merchantId", request.MerchantID}}...)
+	}
+
+	if request.Type > 0 {
+		filter = append(filter, bson.D{{"type", request.Type}}...)
+	}
+
+	if isPeriod {
+		filter = append(filter,
+			bson.D{
+				{"createdAt", bson.D{
+					{"$gte", request.Periods.StartDate.UnixMilli()},
+					{"$lte", request.Periods.EndDate.UnixMilli()},
+				}},
+			}...)
+	}
+
+	return filter
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOperation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(provider) })
+
+	tests := []struct {
+		name   string
+		err    error
+		status codes.Code
+		events int
+	}{
+		{"succeeded", nil, codes.Unset, 0},
+		{"not found", mongo.ErrNoDocuments, codes.Unset, 0},
+		{"failed", errors.New("write conflict"), codes.Error, 1},
+	}
+
+	for _, tt := range tests {
+		ctx, finish := operation(context.Background(), tt.name, time.Second)
+		finish(tt.err)
+
+		if ctx.Err() == nil {
+			t.Errorf("%s: context is not cancelled", tt.name)
+		}
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != len(tests) {
+		t.Fatalf("got %d ended spans, want %d", len(spans), len(tests))
+	}
+
+	for i, tt := range tests {
+		if spans[i].Name() != tt.name || spans[i].Status().Code != tt.status || len(spans[i].Events()) != tt.events {
+			t.Errorf("%s: got span %s with status %v and %d events", tt.name, spans[i].Name(), spans[i].Status().Code, len(spans[i].Events()))
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/utilities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type StatementRepository struct {
+	Entity *entity.BalanceMovement
+}
+
+func NewStatementRepository() StatementRepository {
+	return StatementRepository{Entity: new(entity.BalanceMovement)}
+}
+
+func getMovementAccountFilter(account *entity.AccountBalance) bson.D {
+	return bson.D{
+		{"partnerId", account.PartnerID},
+		{"merchantId", account.MerchantID},
+		{"terminalId", account.TerminalID},
+	}
+}
+
+// newBalanceMovement returns credit/debit movement of successful balance transaction, see TransactionRepository.UpdateBalance
+func newBalanceMovement(trx *entity.BalanceTransaction) *entity.BalanceMovement {
+	movement := &entity.BalanceMovement{
+		PartnerID:        trx.PartnerID,
+		MerchantID:       trx.MerchantID,
+		TerminalID:       trx.TerminalID,
+		TransType:        trx.TransType,
+		TransDate:        trx.TransDate,
+		TransDateNumeric: trx.TransDateNumeric,
+		ReferenceNo:      trx.ReferenceNo,
+		ReceiptNumber:    trx.ReceiptNumber,
+		PartnerRefNumber: trx.PartnerRefNumber,
+		PartnerTransDate: trx.PartnerTransDate,
+		BalanceAfter:     trx.LastBalance,
+		CreatedAt:        time.Now().UnixMilli(),
+	}
+
+	switch trx.TransType {
+	case utilities.TransTypeTopUp:
+		movement.Credit = trx.TotalAmount
+	case utilities.TransTypePayment:
+		movement.Debit = trx.TotalAmount
+	case utilities.TransTypeDistribution:
+		// merchant account is the distributor, members are the receiver
+		if trx.TerminalID == "" {
+			movement.Debit = trx.TotalAmount
+		} else {
+			movement.Credit = trx.TotalAmount
+		}
+	case utilities.TransTypeAdjustment:
+		if trx.TotalAmount < 0 {
+			movement.Debit = -trx.TotalAmount
+		} else {
+			movement.Credit = trx.TotalAmount
+		}
+	}
+
+	return movement
+}
+
+func (s *StatementRepository) Create(ctx context.Context) (_ interface{}, err error) {
+	ctx, finish := operation(ctx, "StatementRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.BalanceMovement.InsertOne(ctx, s.Entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.InsertedID, nil
+}
+
+// FindMovements returns account movements between start and end (inclusive), ordered by transaction date
+func (s *StatementRepository) FindMovements(ctx context.Context, account *entity.AccountBalance, start, end time.Time) (_ []entity.BalanceMovement, err error) {
+	filter := append(getMovementAccountFilter(account), bson.D{
+		{"transDateNumeric", bson.D{
+			{"$gte", start.UnixMilli()},
+			{"$lte", end.UnixMilli()},
+		}},
+	}...)
+
+	ctx, finish := operation(ctx, "StatementRepository.FindMovements", 5*time.Second)
+	defer func() { finish(err) }()
+
+	cursor, err := db.Mongo.Collection.BalanceMovement.Find(
+		ctx,
+		filter,
+		options.Find().SetSort(bson.D{{"transDateNumeric", 1}, {"_id", 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := make([]entity.BalanceMovement, 0)
+	if err = cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+// BalanceAt returns account balance at the given time.
+// it's calculated from the first movement after t, or current account balance if there's no movement since t.
+func (s *StatementRepository) BalanceAt(ctx context.Context, account *entity.AccountBalance, t time.Time) (_ int64, err error) {
+	filter := append(getMovementAccountFilter(account), bson.D{
+		{"transDateNumeric", bson.D{{"$gt", t.UnixMilli()}}},
+	}...)
+
+	ctx, finish := operation(ctx, "StatementRepository.BalanceAt", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	var movement entity.BalanceMovement
+	err = db.Mongo.Collection.BalanceMovement.FindOne(
+		ctx,
+		filter,
+		options.FindOne().SetSort(bson.D{{"transDateNumeric", 1}, {"_id", 1}}),
+	).Decode(&movement)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return account.LastBalanceNumeric, nil
+		}
+		return 0, err
+	}
+
+	return movement.BalanceAfter - movement.Credit + movement.Debit, nil
+}
+
+// FindByPartnerRef returns movement of partner transaction recorded since the given time, see TransactionRequestRepository.Reserve
+func (s *StatementRepository) FindByPartnerRef(ctx context.Context, partnerID, partnerRefNumber string, transType int, since time.Time) (_ *entity.BalanceMovement, err error) {
+	filter := bson.D{
+		{"partnerId", partnerID},
+		{"partnerRefNumber", partnerRefNumber},
+		{"transType", transType},
+		{"createdAt", bson.D{{"$gte", since.UnixMilli()}}},
+	}
+
+	ctx, finish := operation(ctx, "StatementRepository.FindByPartnerRef", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	movement := new(entity.BalanceMovement)
+	err = db.Mongo.Collection.BalanceMovement.FindOne(ctx, filter).Decode(movement)
+	if err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
+
+// HasDistributionCredit returns true when the member account has been credited by the partner balance distribution
+func (s *StatementRepository) HasDistributionCredit(ctx context.Context, account *entity.AccountBalance, partnerRefNumber, referenceNo string) (_ bool, err error) {
+	filter := append(getMovementAccountFilter(account), bson.D{
+		{"transType", utilities.TransTypeDistribution},
+		{"partnerRefNumber", partnerRefNumber},
+		{"referenceNo", referenceNo},
+		{"credit", bson.D{{"$gt", 0}}},
+	}...)
+
+	ctx, finish := operation(ctx, "StatementRepository.HasDistributionCredit", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	count, err := db.Mongo.Collection.BalanceMovement.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// FindPartnerTopUps returns partner top-up movements that either has one of refNumbers, or processed between start and end
+func (s *StatementRepository) FindPartnerTopUps(ctx context.Context, partnerID string, refNumbers []string, start, end time.Time) (_ []entity.BalanceMovement, err error) {
+	filter := bson.D{
+		{"partnerId", partnerID},
+		{"transType", utilities.TransTypeTopUp},
+		{"$or", bson.A{
+			bson.D{{"partnerRefNumber", bson.D{{"$in", refNumbers}}}},
+			bson.D{{"transDateNumeric", bson.D{
+				{"$gte", start.UnixMilli()},
+				{"$lte", end.UnixMilli()},
+			}}},
+		}},
+	}
+
+	ctx, finish := operation(ctx, "StatementRepository.FindPartnerTopUps", 30*time.Second)
+	defer func() { finish(err) }()
+
+	cursor, err := db.Mongo.Collection.BalanceMovement.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := make([]entity.BalanceMovement, 0)
+	if err = cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+// ----------------- SNAPSHOTS ----------------
+
+type SnapshotRepository struct {
+	Entity *entity.BalanceSnapshot
+}
+
+func NewSnapshotRepository() SnapshotRepository {
+	return SnapshotRepository{Entity: new(entity.BalanceSnapshot)}
+}
+
+// Upsert create or replace daily snapshot of current Entity (accountId + snapshotDate)
+func (s *SnapshotRepository) Upsert(ctx context.Context) (err error) {
+	filter := bson.D{
+		{"accountId", s.Entity.AccountID},
+		{"snapshotDate", s.Entity.SnapshotDate},
+	}
+
+	ctx, finish := operation(ctx, "SnapshotRepository.Upsert", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.BalanceSnapshot.ReplaceOne(ctx, filter, s.Entity, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// snapshot is inserted by concurrent upsert, replace it
+		_, err = db.Mongo.Collection.BalanceSnapshot.ReplaceOne(ctx, filter, s.Entity)
+	}
+	return err
+}
+
+func (s *SnapshotRepository) FindByDate(ctx context.Context, accountID, date string) (_ *entity.BalanceSnapshot, err error) {
+	filter := bson.D{
+		{"accountId", accountID},
+		{"snapshotDate", date},
+	}
+
+	ctx, finish := operation(ctx, "SnapshotRepository.FindByDate", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	var snapshot entity.BalanceSnapshot
+	err = db.Mongo.Collection.BalanceSnapshot.FindOne(ctx, filter).Decode(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// ClaimRun claim scheduled snapshot of the date for lease duration, so it's taken by single worker.
+// it returns false when the snapshot has been completed or it's being taken by other worker
+func (s *SnapshotRepository) ClaimRun(ctx context.Context, date string, lease time.Duration) (_ bool, err error) {
+	now := time.Now()
+	filter := bson.D{
+		{"_id", date},
+		{"status", bson.D{{"$ne", utilities.JobStatusCompleted}}},
+		{"leaseExpiredAt", bson.D{{"$lte", now.UnixMilli()}}},
+	}
+	update := bson.D{{"$set", bson.D{
+		{"status", utilities.JobStatusProcessing},
+		{"leaseExpiredAt", now.Add(lease).UnixMilli()},
+		{"startedAt", now.UnixMilli()},
+	}}}
+
+	ctx, finish := operation(ctx, "SnapshotRepository.ClaimRun", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	// existing run which doesn't match the filter is inserted again, and rejected by its _id
+	_, err = db.Mongo.Collection.SnapshotRun.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompleteRun mark claimed snapshot of the date as completed, see ClaimRun
+func (s *SnapshotRepository) CompleteRun(ctx context.Context, date string, total int) (err error) {
+	ctx, finish := operation(ctx, "SnapshotRepository.CompleteRun", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.SnapshotRun.UpdateOne(ctx, bson.D{{"_id", date}}, bson.D{{"$set", bson.D{
+		{"status", utilities.JobStatusCompleted},
+		{"total", total},
+		{"finishedAt", time.Now().UnixMilli()},
+	}}})
+	return err
+}
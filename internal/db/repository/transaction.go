@@ -2,13 +2,18 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db"
 	"github.com/dw-account-service/internal/db/entity"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"time"
 )
 
+// ErrBalanceChanged is returned by UpdateBalance when the account balance has been changed by other transaction
+// since it was read, the transaction must be validated again with the current balance
+var ErrBalanceChanged = apperror.New(apperror.CodeConflict, "account balance has been changed by other transaction")
+
 type TransactionRepository struct {
 	Entity *entity.BalanceTransaction
 }
@@ -17,10 +22,15 @@ func NewTransactionRepository() TransactionRepository {
 	return TransactionRepository{Entity: new(entity.BalanceTransaction)}
 }
 
-// UpdateBalance :
-func (t *TransactionRepository) UpdateBalance() (*entity.AccountBalance, error) {
+// UpdateBalance set last balance of the Entity account and record the Entity as balance movement of the account,
+// both are written in a single transaction so account balance is never changed without its movement.
+// Entity must be a successful transaction, with its transaction date, receipt number and last balance.
+// balance is only updated when its still currentBalance (the balance the transaction is calculated from),
+// otherwise ErrBalanceChanged is returned
+func (t *TransactionRepository) UpdateBalance(ctx context.Context, currentBalance int64) (_ *entity.AccountBalance, err error) {
+	ctx, finish := operation(ctx, "TransactionRepository.UpdateBalance", 5*time.Second)
+	defer func() { finish(err) }()
 
-	// 1. update balance on current document
 	filter := bson.D{
 		{"partnerId", t.Entity.PartnerID},
 		{"merchantId", t.Entity.MerchantID},
@@ -34,18 +44,34 @@ func (t *TransactionRepository) UpdateBalance() (*entity.AccountBalance, error)
 		}},
 	}
 
-	updateResult, err := db.Mongo.Collection.Account.UpdateOne(context.TODO(), filter, update)
-	if err != nil {
-		return nil, err
-	}
+	account := new(entity.AccountBalance)
+	err = inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		// 1. update balance on current document
+		updateResult, err := db.Mongo.Collection.Account.UpdateOne(ctx, append(filter, bson.D{{"lastBalanceNumeric", currentBalance}}...), update)
+		if err != nil {
+			return err
+		}
 
-	if updateResult.ModifiedCount == 0 {
-		return nil, errors.New("update balance failed, cannot find account with current id")
-	}
+		if updateResult.MatchedCount == 0 {
+			count, err := db.Mongo.Collection.Account.CountDocuments(ctx, filter)
+			if err != nil {
+				return err
+			}
 
-	// 2. fetch current updated document
-	account := new(entity.AccountBalance)
-	err = db.Mongo.Collection.Account.FindOne(context.Background(), filter).Decode(account)
+			if count == 0 {
+				return apperror.New(apperror.CodeAccountNotFound, "update balance failed, cannot find account with current id")
+			}
+			return ErrBalanceChanged
+		}
+
+		// 2. record balance movement for account statement
+		if _, err = db.Mongo.Collection.BalanceMovement.InsertOne(ctx, newBalanceMovement(t.Entity)); err != nil {
+			return err
+		}
+
+		// 3. fetch current updated document
+		return db.Mongo.Collection.Account.FindOne(ctx, filter).Decode(account)
+	})
 	if err != nil {
 		return nil, err
 	}
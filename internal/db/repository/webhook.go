@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/utilities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type WebhookRepository struct {
+	Entity *entity.PartnerWebhook
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return WebhookRepository{Entity: new(entity.PartnerWebhook)}
+}
+
+func (w *WebhookRepository) FindByPartnerID(ctx context.Context, partnerID string) (_ *entity.PartnerWebhook, err error) {
+	ctx, finish := operation(ctx, "WebhookRepository.FindByPartnerID", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	webhook := new(entity.PartnerWebhook)
+	err = db.Mongo.Collection.PartnerWebhook.FindOne(ctx, bson.D{{"partnerId", partnerID}}).Decode(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// Upsert create or replace webhook configuration of current Entity partner
+func (w *WebhookRepository) Upsert(ctx context.Context) (err error) {
+	ctx, finish := operation(ctx, "WebhookRepository.Upsert", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.PartnerWebhook.ReplaceOne(
+		ctx,
+		bson.D{{"partnerId", w.Entity.PartnerID}},
+		w.Entity,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+type WebhookDeliveryRepository struct {
+	Entity *entity.WebhookDelivery
+}
+
+func NewWebhookDeliveryRepository() WebhookDeliveryRepository {
+	return WebhookDeliveryRepository{Entity: new(entity.WebhookDelivery)}
+}
+
+func (w *WebhookDeliveryRepository) Create(ctx context.Context) (_ string, err error) {
+	ctx, finish := operation(ctx, "WebhookDeliveryRepository.Create", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	result, err := db.Mongo.Collection.WebhookDelivery.InsertOne(ctx, w.Entity)
+	if err != nil {
+		return "", err
+	}
+
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	w.Entity.ID = id
+
+	return id, nil
+}
+
+func (w *WebhookDeliveryRepository) FindByID(ctx context.Context, id string) (_ *entity.WebhookDelivery, err error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, finish := operation(ctx, "WebhookDeliveryRepository.FindByID", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	delivery := new(entity.WebhookDelivery)
+	err = db.Mongo.Collection.WebhookDelivery.FindOne(ctx, bson.D{{"_id", objectID}}).Decode(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// ClaimDue pick single pending delivery which is due to be attempted, and postpone its next attempt by lease duration,
+// so the delivery is not attempted by other worker at the same time. it returns mongo.ErrNoDocuments when nothing is due
+func (w *WebhookDeliveryRepository) ClaimDue(ctx context.Context, lease time.Duration) (_ *entity.WebhookDelivery, err error) {
+	now := time.Now()
+
+	ctx, finish := operation(ctx, "WebhookDeliveryRepository.ClaimDue", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	delivery := new(entity.WebhookDelivery)
+	err = db.Mongo.Collection.WebhookDelivery.FindOneAndUpdate(
+		ctx,
+		bson.D{
+			{"status", utilities.TrxStatusPending},
+			{"nextAttemptAt", bson.D{{"$lte", now.UnixMilli()}}},
+		},
+		bson.D{{"$set", bson.D{{"nextAttemptAt", now.Add(lease).UnixMilli()}}}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{"nextAttemptAt", 1}}).
+			SetReturnDocument(options.After),
+	).Decode(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// ResetFailed move callback failed delivery back to pending with fresh attempts, so it's sent by the delivery worker.
+// it returns mongo.ErrNoDocuments when the delivery doesn't exist or it's not callback failed
+func (w *WebhookDeliveryRepository) ResetFailed(ctx context.Context, id string) (_ *entity.WebhookDelivery, err error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+
+	ctx, finish := operation(ctx, "WebhookDeliveryRepository.ResetFailed", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	delivery := new(entity.WebhookDelivery)
+	err = db.Mongo.Collection.WebhookDelivery.FindOneAndUpdate(
+		ctx,
+		bson.D{{"_id", objectID}, {"status", utilities.TrxStatusCallbackFailed}},
+		bson.D{{"$set", bson.D{
+			{"status", utilities.TrxStatusPending},
+			{"attempts", 0},
+			{"nextAttemptAt", now},
+			{"updatedAt", now},
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// UpdateAttempt store result of delivery attempt
+func (w *WebhookDeliveryRepository) UpdateAttempt(ctx context.Context, delivery *entity.WebhookDelivery) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(delivery.ID)
+	if err != nil {
+		return err
+	}
+
+	delivery.UpdatedAt = time.Now().UnixMilli()
+	update := bson.D{
+		{"$set", bson.D{
+			{"status", delivery.Status},
+			{"attempts", delivery.Attempts},
+			{"lastStatusCode", delivery.LastStatusCode},
+			{"lastError", delivery.LastError},
+			{"nextAttemptAt", delivery.NextAttemptAt},
+			{"deliveredAt", delivery.DeliveredAt},
+			{"updatedAt", delivery.UpdatedAt},
+		}},
+	}
+
+	ctx, finish := operation(ctx, "WebhookDeliveryRepository.UpdateAttempt", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	_, err = db.Mongo.Collection.WebhookDelivery.UpdateOne(ctx, bson.D{{"_id", objectID}}, update)
+	return err
+}
+
+// FindFailed returns callback failed deliveries, filtered by partner id when its set, newest first
+func (w *WebhookDeliveryRepository) FindFailed(ctx context.Context, partnerID string, page, size int64) (_ []entity.WebhookDelivery, _ int64, err error) {
+	filter := bson.D{{"status", utilities.TrxStatusCallbackFailed}}
+	if partnerID != "" {
+		filter = append(filter, bson.D{{"partnerId", partnerID}}...)
+	}
+
+	ctx, finish := operation(ctx, "WebhookDeliveryRepository.FindFailed", 1500*time.Millisecond)
+	defer func() { finish(err) }()
+
+	cursor, err := db.Mongo.Collection.WebhookDelivery.Find(
+		ctx,
+		filter,
+		options.Find().
+			SetSort(bson.D{{"updatedAt", -1}}).
+			SetSkip((page-1)*size).
+			SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalDocs, _ := db.Mongo.Collection.WebhookDelivery.CountDocuments(ctx, filter)
+	deliveries := make([]entity.WebhookDelivery, 0)
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, totalDocs, nil
+}
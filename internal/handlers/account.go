@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db"
 	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/db/repository"
 	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/dw-account-service/internal/middleware"
+	"github.com/dw-account-service/internal/scheduler"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/dw-account-service/internal/utilities/crypt"
 	"github.com/gofiber/fiber/v2"
@@ -30,8 +33,8 @@ func NewAccountHandler() AccountHandler {
 	}
 }
 
-func (a *AccountHandler) existsAccount() (bool, error) {
-	_, err := a.repo.FindOne()
+func (a *AccountHandler) existsAccount(ctx context.Context) (bool, error) {
+	_, err := a.repo.FindOne(ctx)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return false, nil
@@ -41,31 +44,27 @@ func (a *AccountHandler) existsAccount() (bool, error) {
 	return true, nil
 }
 
-func (a *AccountHandler) Register(c *fiber.Ctx) error {
-	var err error
+// registrationError is returned by registerAccount when registration failed after request validation
+type registrationError struct {
+	prefix string
+	err    error
+}
 
-	// new account struct
-	payload := new(entity.AccountBalance)
+func (r *registrationError) Error() string {
+	return fmt.Sprintf("%s%s", r.prefix, r.err.Error())
+}
 
-	// parse body payload
-	if err = c.BodyParser(payload); err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
-	}
+func (r *registrationError) Unwrap() error {
+	return r.err
+}
 
+// registerAccount validate and create new account from payload, it returns validation messages when request validation failed.
+// its used by single and bulk registration
+func (a *AccountHandler) registerAccount(ctx context.Context, payload *entity.AccountBalance) (*entity.AccountBalance, interface{}, error) {
 	// validate request
 	validation, err := validator.ValidateRequest(payload)
 	if err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data: map[string]interface{}{
-				"errors": validation,
-			},
-		})
+		return nil, validation, err
 	}
 
 	// set default value for accountBalance document
@@ -88,33 +87,61 @@ func (a *AccountHandler) Register(c *fiber.Ctx) error {
 
 	a.repo.Entity = payload
 
-	exists, err := a.existsAccount()
+	exists, err := a.existsAccount(ctx)
 	if !exists && err != nil {
-		return SendDefaultErrResponse("failed to validate existing account, ", err, c)
+		return nil, nil, &registrationError{prefix: "failed to validate existing account, ", err: err}
 	}
 
 	if exists {
+		return nil, nil, apperror.ErrAccountAlreadyExists
+	}
+
+	insertedId, err := a.repo.Create(ctx)
+	if err != nil {
+		return nil, nil, &registrationError{err: err}
+	}
+
+	createdAccount, err := a.repo.FindByID(ctx, insertedId)
+	if err != nil {
+		return nil, nil, &registrationError{prefix: "cannot fetch current registered account, ", err: err}
+	}
+
+	return createdAccount, nil, nil
+}
+
+func (a *AccountHandler) Register(c *fiber.Ctx) error {
+	var err error
+
+	// new account struct
+	payload := new(entity.AccountBalance)
+
+	// parse body payload
+	if err = parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	createdAccount, validation, err := a.registerAccount(c.UserContext(), payload)
+	if validation != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	if errors.Is(err, apperror.ErrAccountAlreadyExists) {
 		responseData := map[string]interface{}{"partnerId": payload.PartnerID, "merchantId": payload.MerchantID}
 
 		if payload.Type == utilities.AccountTypeRegular {
 			responseData["terminalId"] = payload.TerminalID
 		}
 
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: "account already exists, or its probably in deactivated status. ",
-			Data:    responseData,
-		})
-	}
-
-	insertedId, err := a.repo.Create()
-	if err != nil {
-		return SendDefaultErrResponse("", err, c)
+		return SendErrResponse(err, responseData, c)
 	}
 
-	createdAccount, err := a.repo.FindByID(insertedId)
-	if err != nil {
-		return SendDefaultErrResponse("cannot fetch current registered account, ", err, c)
+	var rErr *registrationError
+	if errors.As(err, &rErr) {
+		return SendDefaultErrResponse(rErr.prefix, rErr.err, c)
 	}
 
 	return c.Status(201).JSON(entity.Responses{
@@ -130,12 +157,16 @@ func (a *AccountHandler) Unregister(c *fiber.Ctx) error {
 	payload := new(entity.UnregisterAccount)
 
 	// parse body payload
-	if err := c.BodyParser(payload); err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
 	}
 
 	// check is valid account
@@ -143,20 +174,16 @@ func (a *AccountHandler) Unregister(c *fiber.Ctx) error {
 	a.repo.Entity.MerchantID = payload.MerchantID
 	a.repo.Entity.TerminalID = payload.TerminalID
 
-	exists, err := a.existsAccount()
+	exists, err := a.existsAccount(c.UserContext())
 	if !exists {
 		if err != nil {
 			return SendDefaultErrResponse("failed to validate existing account, ", err, c)
 		}
 
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: "account not found",
-			Data:    nil,
-		})
+		return SendErrResponse(apperror.ErrAccountNotFound, nil, c)
 	}
 
-	err = a.repo.DeactivateAccount(payload)
+	err = a.repo.DeactivateAccount(c.UserContext(), payload)
 	if err != nil {
 		return SendDefaultErrResponse("", err, c)
 	}
@@ -167,11 +194,11 @@ func (a *AccountHandler) Unregister(c *fiber.Ctx) error {
 	payload.UpdatedAt = auditLog
 
 	//var acc entity.AccountBalance
-	doc, _ := a.repo.FindOne()
+	doc, _ := a.repo.FindOne(c.UserContext())
 	payload.Type = doc.Type
 	payload.UniqueID = doc.UniqueID
 
-	_, err = a.repo.InsertDeactivatedAccount(payload)
+	_, err = a.repo.InsertDeactivatedAccount(c.UserContext(), payload)
 	if err != nil {
 		return SendDefaultErrResponse("failed on insert deactivated account data, ", err, c)
 	}
@@ -183,12 +210,208 @@ func (a *AccountHandler) Unregister(c *fiber.Ctx) error {
 	})
 }
 
+// ReactivateAccount re-activate deactivated account, and remove its deactivation record
+func (a *AccountHandler) ReactivateAccount(c *fiber.Ctx) error {
+	payload := new(entity.ReactivateAccountRequest)
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	// use request repository, so its entity is not shared with other request
+	repo := repository.NewAccountRepository()
+	repo.Entity = &entity.AccountBalance{
+		PartnerID:  payload.PartnerID,
+		MerchantID: payload.MerchantID,
+		TerminalID: payload.TerminalID,
+	}
+
+	// merchant account is the only account without terminalId
+	if payload.TerminalID == "" {
+		repo.Entity.Type = utilities.AccountTypeMerchant
+	}
+
+	account, err := repo.FindOne(c.UserContext())
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch account, ", accountErr(err), c)
+	}
+
+	if account.Active {
+		return SendErrResponse(apperror.New(apperror.CodeConflict, "account is already active"), nil, c)
+	}
+
+	updatedAccount, err := repo.Reactivate(c.UserContext(), account)
+	if err != nil {
+		return SendDefaultErrResponse("failed to reactivate account, ", err, c)
+	}
+	middleware.SetAffectedCount(c, 1)
+
+	_, err = repo.InsertAuditTrail(c.UserContext(), &entity.AccountAuditTrail{
+		AccountID: account.ID,
+		Action:    "reactivate",
+		OldValues: map[string]interface{}{"active": false},
+		NewValues: map[string]interface{}{"active": true},
+		Reason:    payload.Reason,
+		UpdatedBy: middleware.Subject(c),
+		CreatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		utilities.Logger(c.UserContext()).Error().Err(err).Str("accountId", account.ID).Msg("failed to insert account audit trail")
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "reactivation successful",
+		Data:    updatedAccount,
+	})
+}
+
+// UpdateAccount update mutable fields (terminalName, merchantId, terminalId) of a regular account.
+// changing merchantId/terminalId will regenerate uniqueId, and every change is recorded into account audit trail.
+func (a *AccountHandler) UpdateAccount(c *fiber.Ctx) error {
+	payload := new(entity.UpdateAccountRequest)
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	// use dedicated handler, so repository entity is not shared with other request
+	handler := NewAccountHandler()
+	handler.repo.Entity = &entity.AccountBalance{
+		PartnerID:  payload.PartnerID,
+		MerchantID: payload.MerchantID,
+		TerminalID: payload.TerminalID,
+		Type:       utilities.AccountTypeRegular,
+	}
+
+	account, err := handler.repo.FindOne(c.UserContext())
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch account, ", accountErr(err), c)
+	}
+
+	oldValues := map[string]interface{}{}
+	newValues := map[string]interface{}{}
+	fields := bson.D{}
+
+	if v := payload.Changes.TerminalName; v != nil && *v != account.TerminalName {
+		oldValues["terminalName"] = account.TerminalName
+		newValues["terminalName"] = *v
+		fields = append(fields, bson.D{{"terminalName", *v}}...)
+	}
+
+	newMerchantID, newTerminalID := account.MerchantID, account.TerminalID
+	if v := payload.Changes.MerchantID; v != nil && *v != account.MerchantID {
+		newMerchantID = strings.TrimSpace(*v)
+	}
+	if v := payload.Changes.TerminalID; v != nil && *v != account.TerminalID {
+		newTerminalID = strings.TrimSpace(*v)
+	}
+
+	// identity changes
+	if newMerchantID != account.MerchantID || newTerminalID != account.TerminalID {
+		if newMerchantID == "" || newTerminalID == "" {
+			return SendValidationErrResponse("merchantId and terminalId cannot be changed to empty value", c)
+		}
+
+		// moving member to another merchant, the destination merchant must be registered
+		if newMerchantID != account.MerchantID {
+			handler.repo.Entity = &entity.AccountBalance{
+				PartnerID:  account.PartnerID,
+				MerchantID: newMerchantID,
+				Type:       utilities.AccountTypeMerchant,
+			}
+
+			exists, err := handler.existsAccount(c.UserContext())
+			if err != nil {
+				return SendDefaultErrResponse("failed to validate destination merchant, ", err, c)
+			}
+
+			if !exists {
+				return SendErrResponse(apperror.New(apperror.CodeAccountNotFound, "destination merchant account not found"), nil, c)
+			}
+		}
+
+		handler.repo.Entity = &entity.AccountBalance{
+			PartnerID:  account.PartnerID,
+			MerchantID: newMerchantID,
+			TerminalID: newTerminalID,
+		}
+
+		exists, err := handler.existsAccount(c.UserContext())
+		if err != nil {
+			return SendDefaultErrResponse("failed to validate existing account, ", err, c)
+		}
+
+		if exists {
+			return SendErrResponse(apperror.New(apperror.CodeDuplicate, "account with the new merchantId and terminalId already exists"), nil, c)
+		}
+
+		newUniqueID := fmt.Sprintf("%s%s", newMerchantID, newTerminalID)
+
+		oldValues["merchantId"] = account.MerchantID
+		oldValues["terminalId"] = account.TerminalID
+		oldValues["uniqueId"] = account.UniqueID
+		newValues["merchantId"] = newMerchantID
+		newValues["terminalId"] = newTerminalID
+		newValues["uniqueId"] = newUniqueID
+
+		fields = append(fields, bson.D{
+			{"merchantId", newMerchantID},
+			{"terminalId", newTerminalID},
+			{"uniqueId", newUniqueID},
+		}...)
+	}
+
+	if len(fields) == 0 {
+		return SendValidationErrResponse("no changes to be updated", c)
+	}
+
+	// account identity and its audit trail are updated together, the actor is the authenticated caller
+	updatedAccount, err := handler.repo.UpdateIdentity(c.UserContext(), account, fields, &entity.AccountAuditTrail{
+		AccountID: account.ID,
+		Action:    "update-profile",
+		OldValues: oldValues,
+		NewValues: newValues,
+		Reason:    payload.Reason,
+		UpdatedBy: middleware.Caller(c),
+		CreatedAt: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return SendDefaultErrResponse("failed to update account, ", err, c)
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "account successfully updated",
+		Data:    updatedAccount,
+	})
+}
+
 func (a *AccountHandler) GetAccountByID(c *fiber.Ctx) error {
-	id, _ := primitive.ObjectIDFromHex(c.Params("id"))
-	account, err := a.repo.FindByID(id)
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return SendValidationErrResponse("invalid account id", c)
+	}
+
+	account, err := a.repo.FindByID(c.UserContext(), id)
+
+	// other partner account is treated as not found
+	if err == nil && !isPartnerAllowed(c, account.PartnerID) {
+		err = mongo.ErrNoDocuments
+	}
 
 	if err != nil {
-		return SendDefaultErrResponse("failed to fetch account, ", err, c)
+		return SendDefaultErrResponse("failed to fetch account, ", accountErr(err), c)
 	}
 
 	return c.Status(200).JSON(entity.Responses{
@@ -205,31 +428,25 @@ func (a *AccountHandler) GetAccount(c *fiber.Ctx) error {
 	payload := new(entity.AccountBalance)
 
 	// parse body payload
-	if err := c.BodyParser(&payload); err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
+	if err := parseBody(c, &payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
 	}
 
 	// validate request
 	validation, err := validator.ValidateRequest(payload)
 	if err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data: map[string]interface{}{
-				"errors": validation,
-			},
-		})
+		return SendInvalidRequestResponse(err, validation, c)
 	}
 
 	a.repo.Entity = payload
-	account, err := a.repo.FindOne()
+	account, err := a.repo.FindOne(c.UserContext())
 
 	if err != nil {
-		return SendDefaultErrResponse("failed to fetch account, ", err, c)
+		return SendDefaultErrResponse("failed to fetch account, ", accountErr(err), c)
 	}
 
 	return c.Status(200).JSON(entity.Responses{
@@ -245,26 +462,22 @@ func (a *AccountHandler) GetAccountsPaginated(c *fiber.Ctx) error {
 	var req = new(entity.PaginatedAccountRequest)
 
 	// parse body payload
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
+	if err := parseBody(c, &req); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &req.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
 	}
 
 	msgResponse := "accounts successfully fetched"
 	req.Status = strings.ToLower(req.Status)
 
-	validStatus := map[string]interface{}{"all": 0, "active": 1, "deactivated": 2}
+	if validation, err := validator.ValidateRequest(req); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
 	if req.Status != "" {
-		if _, ok := validStatus[req.Status]; !ok {
-			return c.Status(400).JSON(entity.Responses{
-				Success: false,
-				Message: "invalid status value. its only accept all, active or deactivated",
-				Data:    nil,
-			})
-		}
 		msgResponse = fmt.Sprintf("%s account successfully fetched", req.Status)
 	}
 
@@ -277,7 +490,7 @@ func (a *AccountHandler) GetAccountsPaginated(c *fiber.Ctx) error {
 		req.Size = 10
 	}
 
-	accounts, total, pages, err := a.repo.FindAllPaginated(req)
+	accounts, total, pages, err := a.repo.FindAllPaginated(c.UserContext(), req)
 	if err != nil {
 		return SendDefaultPaginationErrResponse("", err, c)
 	}
@@ -305,49 +518,18 @@ func (a *AccountHandler) GetMerchantMembers(c *fiber.Ctx, isPeriod bool) error {
 	// new account struct
 	payload := new(entity.PaginatedAccountRequest)
 	// parse body payload
-	if err = c.BodyParser(payload); err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
+	if err = parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
 	}
 
 	// validate periods parameter
 	if isPeriod {
-		payload.Periods.StartDate, err = time.ParseInLocation(
-			"20060102150405",
-			fmt.Sprintf("%s%s", payload.Periods.Start, "000000"),
-			time.Now().Location(),
-		)
-		if err != nil {
-			return c.Status(400).JSON(entity.Responses{
-				Success: false,
-				Message: "invalid start periods",
-				Data:    nil,
-			})
-		}
-
-		payload.Periods.EndDate, err = time.ParseInLocation(
-			"20060102150405",
-			fmt.Sprintf("%s%s", payload.Periods.End, "235959"),
-			time.Now().Location(),
-		)
-
-		if err != nil {
-			return c.Status(400).JSON(entity.Responses{
-				Success: false,
-				Message: "invalid end periods",
-				Data:    nil,
-			})
-		}
-
-		if payload.Periods.EndDate.Before(payload.Periods.StartDate) {
-			return c.Status(400).JSON(entity.Responses{
-				Success: false,
-				Message: "end period cannot be less than start period",
-				Data:    nil,
-			})
+		if err = parsePeriods(&payload.Periods); err != nil {
+			return SendErrResponse(err, nil, c)
 		}
 	}
 
@@ -355,20 +537,16 @@ func (a *AccountHandler) GetMerchantMembers(c *fiber.Ctx, isPeriod bool) error {
 	payload.Type = utilities.AccountTypeMerchant
 
 	// validate request
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
 	if payload.PartnerID == "" {
-		return c.Status(400).JSON(entity.PaginatedResponseMembers{
-			Success: false,
-			Message: "partnerId cannot be empty",
-			Data:    nil,
-		})
+		return SendValidationErrResponse("partnerId cannot be empty", c)
 	}
 
 	if payload.MerchantID == "" {
-		return c.Status(400).JSON(entity.PaginatedResponseMembers{
-			Success: false,
-			Message: "merchantId cannot be empty",
-			Data:    nil,
-		})
+		return SendValidationErrResponse("merchantId cannot be empty", c)
 	}
 
 	// set default value
@@ -383,7 +561,7 @@ func (a *AccountHandler) GetMerchantMembers(c *fiber.Ctx, isPeriod bool) error {
 	// re-apply type for filter condition
 	payload.Type = utilities.AccountTypeRegular
 
-	members, total, pages, err := a.repo.FindMembersPaginated(payload, isPeriod)
+	members, total, pages, err := a.repo.FindMembersPaginated(c.UserContext(), payload, isPeriod)
 
 	if err != nil {
 		return SendDefaultPaginationErrResponse("cannot fetch members, ", err, c)
@@ -392,9 +570,9 @@ func (a *AccountHandler) GetMerchantMembers(c *fiber.Ctx, isPeriod bool) error {
 	a.balanceRepo.Entity.MerchantID = payload.MerchantID
 	a.balanceRepo.Entity.PartnerID = payload.PartnerID
 	a.balanceRepo.Entity.Type = utilities.AccountTypeMerchant
-	err = a.balanceRepo.GetLastBalance()
+	err = a.balanceRepo.GetLastBalance(c.UserContext())
 	if err != nil {
-		return SendDefaultPaginationErrResponse("cannot get merchant curren balance, ", err, c)
+		return SendDefaultPaginationErrResponse("cannot get merchant curren balance, ", accountErr(err), c)
 	}
 
 	return c.Status(200).JSON(entity.PaginatedResponseMembers{
@@ -467,7 +645,8 @@ func (a *AccountHandler) UpdateMerchantAndTerminalForAccount(c *fiber.Ctx) error
 				}},
 			})
 		if err2 != nil {
-			utilities.Log.Println("err: ", err2.Error())
+			utilities.Logger(c.UserContext()).Error().Err(err2).Str("accountId", account.ID).Msg("failed to update account")
+			continue
 		}
 
 		//arrAccount = append(arrAccount, account)
@@ -476,6 +655,7 @@ func (a *AccountHandler) UpdateMerchantAndTerminalForAccount(c *fiber.Ctx) error
 
 	}
 
+	middleware.SetAffectedCount(c, successCount)
 	return c.Status(200).JSON(fiber.Map{
 		"success": true,
 		"message": "ok",
@@ -537,7 +717,8 @@ func (a *AccountHandler) SyncBalance(c *fiber.Ctx) error {
 				}},
 			})
 		if err2 != nil {
-			utilities.Log.Println("err: ", err2.Error())
+			utilities.Logger(c.UserContext()).Error().Err(err2).Str("accountId", account.ID).Msg("failed to update account")
+			continue
 		}
 
 		//	arrAccount = append(arrAccount, account)
@@ -546,6 +727,7 @@ func (a *AccountHandler) SyncBalance(c *fiber.Ctx) error {
 
 	}
 
+	middleware.SetAffectedCount(c, successCount)
 	return c.Status(200).JSON(fiber.Map{
 		"success": true,
 		"message": "ok",
@@ -553,3 +735,35 @@ func (a *AccountHandler) SyncBalance(c *fiber.Ctx) error {
 		"data":    nil,
 	})
 }
+
+// TakeBalanceSnapshot store daily closing balance snapshot of every account for requested date (default: yesterday)
+func (a *AccountHandler) TakeBalanceSnapshot(c *fiber.Ctx) error {
+	payload := new(entity.SnapshotRequest)
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, payload); err != nil {
+			return SendValidationErrResponse(err.Error(), c)
+		}
+	}
+
+	date := time.Now().AddDate(0, 0, -1)
+	if payload.Date != "" {
+		var err error
+		date, err = time.ParseInLocation("20060102", payload.Date, time.Now().Location())
+		if err != nil {
+			return SendValidationErrResponse("invalid snapshot date", c)
+		}
+	}
+
+	total, err := scheduler.TakeSnapshot(c.UserContext(), date)
+	if err != nil {
+		return SendDefaultErrResponse("failed to take balance snapshot, ", err, c)
+	}
+
+	middleware.SetAffectedCount(c, int64(total))
+	return c.Status(200).JSON(fiber.Map{
+		"success": true,
+		"message": "ok",
+		"count":   fmt.Sprintf("%d account balance snapshot has been successfully stored", total),
+		"data":    nil,
+	})
+}
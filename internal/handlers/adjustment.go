@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/consumer"
+	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/dw-account-service/internal/middleware"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
+)
+
+const (
+	AdjustmentDirectionCredit = "credit"
+	AdjustmentDirectionDebit  = "debit"
+)
+
+func sendAdjustmentReviewerRequired(c *fiber.Ctx) error {
+	return SendErrResponse(apperror.New(apperror.CodeUnauthorized, "balance adjustment requires authenticated admin"), nil, c)
+}
+
+// SubmitAdjustment submit credit/debit adjustment of an account, balance is not changed until its approved by another admin
+func (a *AdminHandler) SubmitAdjustment(c *fiber.Ctx) error {
+	submitter := middleware.Subject(c)
+	if submitter == "" {
+		return sendAdjustmentReviewerRequired(c)
+	}
+
+	payload := new(entity.BalanceAdjustmentRequest)
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	payload.Direction = strings.ToLower(payload.Direction)
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	accountRepo := repository.NewAccountRepository()
+	accountRepo.Entity = &entity.AccountBalance{
+		PartnerID:  payload.PartnerID,
+		MerchantID: payload.MerchantID,
+		TerminalID: payload.TerminalID,
+	}
+	if payload.TerminalID == "" {
+		accountRepo.Entity.Type = utilities.AccountTypeMerchant
+	}
+
+	account, err := accountRepo.FindOne(c.UserContext())
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch account, ", accountErr(err), c)
+	}
+
+	if !account.Active {
+		return SendErrResponse(apperror.ErrAccountDeactivated, nil, c)
+	}
+
+	adjustmentRepo := repository.NewAdjustmentRepository()
+	adjustmentRepo.Entity = &entity.BalanceAdjustment{
+		PartnerID:   payload.PartnerID,
+		MerchantID:  payload.MerchantID,
+		TerminalID:  payload.TerminalID,
+		Direction:   payload.Direction,
+		Amount:      payload.Amount,
+		Reason:      payload.Reason,
+		Status:      utilities.AdjustmentStatusPending,
+		SubmittedBy: submitter,
+		SubmittedAt: time.Now().UnixMilli(),
+	}
+
+	if _, err = adjustmentRepo.Create(c.UserContext()); err != nil {
+		return SendDefaultErrResponse("failed to submit balance adjustment, ", err, c)
+	}
+
+	return c.Status(201).JSON(entity.Responses{
+		Success: true,
+		Message: "balance adjustment has been submitted, waiting for approval",
+		Data:    adjustmentRepo.Entity,
+	})
+}
+
+// reviewAdjustment make sure the adjustment is still pending, then set its review status
+func reviewAdjustment(c *fiber.Ctx, status, message string) (*entity.BalanceAdjustment, error) {
+	reviewer := middleware.Subject(c)
+	if reviewer == "" {
+		return nil, sendAdjustmentReviewerRequired(c)
+	}
+
+	adjustmentRepo := repository.NewAdjustmentRepository()
+	adjustment, err := adjustmentRepo.FindByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return nil, SendDefaultErrResponse("failed to fetch balance adjustment, ", err, c)
+	}
+
+	if status == utilities.AdjustmentStatusApproved && adjustment.SubmittedBy == reviewer {
+		return nil, SendErrResponse(apperror.New(apperror.CodeForbidden, "balance adjustment must be approved by other admin than its submitter"), nil, c)
+	}
+
+	adjustment, err = adjustmentRepo.Review(c.UserContext(), c.Params("id"), status, reviewer, message)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, SendErrResponse(apperror.New(apperror.CodeConflict, "balance adjustment has already been reviewed"), nil, c)
+		}
+		return nil, SendDefaultErrResponse("failed to review balance adjustment, ", err, c)
+	}
+
+	return adjustment, nil
+}
+
+// ApproveAdjustment approve pending adjustment and apply it into account balance as adjustment transaction
+func (a *AdminHandler) ApproveAdjustment(c *fiber.Ctx) error {
+	adjustment, err := reviewAdjustment(c, utilities.AdjustmentStatusApproved, "")
+	if adjustment == nil {
+		return err
+	}
+
+	amount := adjustment.Amount
+	if adjustment.Direction == AdjustmentDirectionDebit {
+		amount = -amount
+	}
+
+	handler := consumer.NewTransactionHandler()
+	trx, err := handler.Process(c.UserContext(), &entity.BalanceTransaction{
+		TransType:   utilities.TransTypeAdjustment,
+		ReferenceNo: adjustment.ID,
+		PartnerID:   adjustment.PartnerID,
+		MerchantID:  adjustment.MerchantID,
+		TerminalID:  adjustment.TerminalID,
+		TotalAmount: amount,
+		Items: []entity.TransactionItem{{
+			Name:   "Balance Adjustment",
+			Amount: adjustment.Amount,
+			Qty:    1,
+		}},
+		RequestDetail: entity.RequestDetail{
+			Origin:    "admin",
+			Timestamp: time.Now().Format("20060102150405"),
+		},
+	})
+
+	if err != nil {
+		adjustment.Status = utilities.AdjustmentStatusFailed
+		adjustment.Message = apperror.From(err).ClientMessage()
+	} else {
+		adjustment.ReceiptNumber = trx.ReceiptNumber
+		adjustment.LastBalance = trx.LastBalance
+		middleware.SetAffectedCount(c, 1)
+	}
+
+	adjustmentRepo := repository.NewAdjustmentRepository()
+	if errUpdate := adjustmentRepo.UpdateResult(c.UserContext(), adjustment); errUpdate != nil {
+		utilities.Logger(c.UserContext()).Error().Err(errUpdate).Str("adjustmentId", adjustment.ID).Msg("failed to update balance adjustment result")
+	}
+
+	if err != nil {
+		appErr := apperror.From(err)
+		logInternalErr(appErr, c)
+		return c.Status(appErr.Status()).JSON(entity.Responses{
+			Success:   false,
+			Message:   "failed to apply balance adjustment, " + appErr.ClientMessage(),
+			ErrorCode: string(appErr.Code),
+			Data:      adjustment,
+		})
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "balance adjustment has been approved",
+		Data:    adjustment,
+	})
+}
+
+// RejectAdjustment reject pending adjustment, rejected adjustment is never applied
+func (a *AdminHandler) RejectAdjustment(c *fiber.Ctx) error {
+	payload := new(entity.BalanceAdjustmentReview)
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, payload); err != nil {
+			return SendValidationErrResponse(err.Error(), c)
+		}
+	}
+
+	adjustment, err := reviewAdjustment(c, utilities.AdjustmentStatusRejected, payload.Reason)
+	if adjustment == nil {
+		return err
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "balance adjustment has been rejected",
+		Data:    adjustment,
+	})
+}
+
+// GetAdjustments returns balance adjustments, filtered by query params: status, page and size
+func (a *AdminHandler) GetAdjustments(c *fiber.Ctx) error {
+	page := int64(c.QueryInt("page", 1))
+	size := int64(c.QueryInt("size", 10))
+	if page < 1 || size < 1 || size > validator.MaxPageSize {
+		return SendDefaultPaginationErrResponse("", apperror.Validation(fmt.Sprintf("page must be greater than 0, and size must be between 1 and %d", validator.MaxPageSize)), c)
+	}
+
+	adjustmentRepo := repository.NewAdjustmentRepository()
+	adjustments, total, pages, err := adjustmentRepo.FindAllPaginated(c.UserContext(), strings.ToLower(c.Query("status")), page, size)
+	if err != nil {
+		return SendDefaultPaginationErrResponse("", err, c)
+	}
+
+	return c.Status(200).JSON(entity.PaginatedResponse{
+		Success: true,
+		Message: "balance adjustments successfully fetched",
+		Data: entity.PaginatedDetailResponse{
+			Result: adjustments,
+			Total:  total,
+			Pagination: entity.PaginationInfo{
+				PerPage:     size,
+				CurrentPage: page,
+				LastPage:    pages,
+			},
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminHandler struct {
+	auditRepo repository.AdminAuditRepository
+}
+
+func NewAdminHandler() AdminHandler {
+	return AdminHandler{
+		auditRepo: repository.NewAdminAuditRepository(),
+	}
+}
+
+// GetAuditLogs returns admin audit logs, filtered by query params: action, caller, start and end (YYYYMMDD), page and size
+func (a *AdminHandler) GetAuditLogs(c *fiber.Ctx) error {
+	req := &entity.AdminAuditLogRequest{
+		Action: c.Query("action"),
+		Caller: c.Query("caller"),
+		Periods: entity.PeriodsRequest{
+			Start: c.Query("start"),
+			End:   c.Query("end"),
+		},
+		Page: int64(c.QueryInt("page", 1)),
+		Size: int64(c.QueryInt("size", 10)),
+	}
+
+	if validation, err := validator.ValidateRequest(req); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	if req.Periods.Start != "" || req.Periods.End != "" {
+		if err := parsePeriods(&req.Periods); err != nil {
+			return SendDefaultPaginationErrResponse("", err, c)
+		}
+	}
+
+	logs, total, pages, err := a.auditRepo.FindAllPaginated(c.UserContext(), req)
+	if err != nil {
+		return SendDefaultPaginationErrResponse("", err, c)
+	}
+
+	return c.Status(200).JSON(entity.PaginatedResponse{
+		Success: true,
+		Message: "audit logs successfully fetched",
+		Data: entity.PaginatedDetailResponse{
+			Result: logs,
+			Total:  total,
+			Pagination: entity.PaginationInfo{
+				PerPage:     req.Size,
+				CurrentPage: req.Page,
+				LastPage:    pages,
+			},
+		},
+	})
+}
@@ -1,43 +1,49 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/validator"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
 )
 
 type BalanceHandler struct {
-	repo repository.BalanceRepository
+	repo          repository.BalanceRepository
+	statementRepo repository.StatementRepository
+	snapshotRepo  repository.SnapshotRepository
 }
 
 func NewBalanceHandler() BalanceHandler {
-	return BalanceHandler{repo: repository.NewBalanceRepository()}
+	return BalanceHandler{
+		repo:          repository.NewBalanceRepository(),
+		statementRepo: repository.NewStatementRepository(),
+		snapshotRepo:  repository.NewSnapshotRepository(),
+	}
 }
 
 func (b *BalanceHandler) Inquiry(c *fiber.Ctx, isMerchant bool) error {
 	payload := new(entity.InquiryBalance)
-	if err := c.BodyParser(&payload); err != nil {
-		return c.Status(400).JSON(entity.Responses{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
+	if err := parseBody(c, &payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
 	}
 
 	payload.Type = utilities.AccountTypeMerchant
 	if !isMerchant {
-		if payload.TerminalID == "" {
-			return c.Status(400).JSON(entity.Responses{
-				Success: false,
-				Message: "terminalId cannot be empty",
-				Data:    nil,
-			})
-		}
-
 		payload.Type = utilities.AccountTypeRegular
 	}
 
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
 	// set empty terminalId if its merchant
 	if payload.Type == utilities.AccountTypeMerchant {
 		payload.TerminalID = ""
@@ -45,9 +51,9 @@ func (b *BalanceHandler) Inquiry(c *fiber.Ctx, isMerchant bool) error {
 
 	b.repo.Entity = payload
 
-	err := b.repo.GetLastBalance()
+	err := b.repo.GetLastBalance(c.UserContext())
 	if err != nil {
-		return SendDefaultErrResponse("failed to inquiry last balance on current merchant, ", err, c)
+		return SendDefaultErrResponse("failed to inquiry last balance on current merchant, ", accountErr(err), c)
 	}
 
 	return c.Status(200).JSON(entity.Responses{
@@ -57,6 +63,96 @@ func (b *BalanceHandler) Inquiry(c *fiber.Ctx, isMerchant bool) error {
 	})
 }
 
+// Statement returns opening balance, every movement and closing balance of an account within requested periods
+func (b *BalanceHandler) Statement(c *fiber.Ctx, isMerchant bool) error {
+	payload := new(entity.StatementRequest)
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	payload.Type = utilities.AccountTypeMerchant
+	if !isMerchant {
+		payload.Type = utilities.AccountTypeRegular
+	}
+
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	if err := parsePeriods(&payload.Periods); err != nil {
+		return SendErrResponse(err, nil, c)
+	}
+
+	if payload.Type == utilities.AccountTypeMerchant {
+		payload.TerminalID = ""
+	}
+
+	// use request repository, so its entity is not shared with other request
+	accountRepo := repository.NewAccountRepository()
+	accountRepo.Entity = &entity.AccountBalance{
+		PartnerID:  payload.PartnerID,
+		MerchantID: payload.MerchantID,
+		TerminalID: payload.TerminalID,
+		Type:       payload.Type,
+	}
+
+	account, err := accountRepo.FindOne(c.UserContext())
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch account, ", accountErr(err), c)
+	}
+
+	// opening balance is the closing balance of the day before start period,
+	// use stored daily snapshot if its available
+	dayBefore := payload.Periods.StartDate.AddDate(0, 0, -1)
+	var openingBalance int64
+
+	snapshot, err := b.snapshotRepo.FindByDate(c.UserContext(), account.ID, dayBefore.Format("20060102"))
+	switch {
+	case err == nil:
+		openingBalance = snapshot.ClosingBalance
+	case errors.Is(err, mongo.ErrNoDocuments):
+		openingBalance, err = b.statementRepo.BalanceAt(c.UserContext(), account, payload.Periods.StartDate.Add(-time.Millisecond))
+		if err != nil {
+			return SendDefaultErrResponse("failed to calculate opening balance, ", err, c)
+		}
+	default:
+		return SendDefaultErrResponse("failed to fetch balance snapshot, ", err, c)
+	}
+
+	movements, err := b.statementRepo.FindMovements(c.UserContext(), account, payload.Periods.StartDate, payload.Periods.EndDate)
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch balance movements, ", err, c)
+	}
+
+	statement := entity.AccountStatement{
+		AccountID:      account.ID,
+		PartnerID:      account.PartnerID,
+		MerchantID:     account.MerchantID,
+		TerminalID:     account.TerminalID,
+		TerminalName:   account.TerminalName,
+		PeriodStart:    payload.Periods.Start,
+		PeriodEnd:      payload.Periods.End,
+		OpeningBalance: openingBalance,
+		Movements:      movements,
+	}
+
+	for _, movement := range movements {
+		statement.TotalCredit += movement.Credit
+		statement.TotalDebit += movement.Debit
+	}
+	statement.ClosingBalance = statement.OpeningBalance + statement.TotalCredit - statement.TotalDebit
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "statement successfully generated",
+		Data:    statement,
+	})
+}
+
 func (b *BalanceHandler) MerchantBalanceSummary(c *fiber.Ctx) error {
 	return nil
 }
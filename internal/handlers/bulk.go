@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maximum rows that can be registered synchronously, larger payload must use async mode
+	bulkRegistrationSyncLimit = 500
+	// maximum rows per bulk registration request
+	bulkRegistrationMaxRows = 50000
+	// number of processed rows before async job progress is stored
+	bulkRegistrationProgressBatch = 100
+	// bulkJobsStopTimeout is additional time for interrupted jobs to store their progress, after shutdown timeout is reached
+	bulkJobsStopTimeout = 5 * time.Second
+)
+
+var (
+	// bulkJobs is running async bulk registration jobs, see StopBulkJobs
+	bulkJobs sync.WaitGroup
+	// bulkJobsStop is closed when shutdown timeout is reached, running jobs stop registering remaining rows
+	bulkJobsStop     = make(chan struct{})
+	bulkJobsStopOnce sync.Once
+)
+
+// StopBulkJobs wait until running async bulk registration jobs are finished. jobs still running when the context is done
+// are interrupted before their next row, and their progress is stored with interrupted status
+func StopBulkJobs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		bulkJobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		bulkJobsStopOnce.Do(func() {
+			close(bulkJobsStop)
+		})
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(bulkJobsStopTimeout):
+		return errors.New("bulk registration jobs are not stopped, their progress is abandoned")
+	}
+}
+
+// parseBulkMembersCSV read terminalId and terminalName columns from csv file, first row must be the column header
+func parseBulkMembersCSV(r io.Reader) ([]entity.BulkRegistrationMember, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty registration file")
+		}
+		return nil, err
+	}
+
+	terminalIDIdx, terminalNameIdx := -1, -1
+	for idx, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "terminalid", "terminal_id":
+			terminalIDIdx = idx
+		case "terminalname", "terminal_name":
+			terminalNameIdx = idx
+		}
+	}
+
+	if terminalIDIdx < 0 {
+		return nil, errors.New("registration file must contains terminalId column")
+	}
+
+	var members []entity.BulkRegistrationMember
+	line := 1
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err.Error())
+		}
+
+		member := entity.BulkRegistrationMember{}
+		if terminalIDIdx < len(row) {
+			member.TerminalID = strings.TrimSpace(row[terminalIDIdx])
+		}
+		if terminalNameIdx >= 0 && terminalNameIdx < len(row) {
+			member.TerminalName = strings.TrimSpace(row[terminalNameIdx])
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// parseBulkRegistrationRequest accept json payload or multipart form (file, partnerId, merchantId, async)
+func parseBulkRegistrationRequest(c *fiber.Ctx) (*entity.BulkRegistrationRequest, error) {
+	req := new(entity.BulkRegistrationRequest)
+
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		if err := parseBody(c, req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+
+	req.PartnerID = c.FormValue("partnerId")
+	req.MerchantID = c.FormValue("merchantId")
+	req.Async, _ = strconv.ParseBool(c.FormValue("async", "false"))
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, errors.New("registration file cannot be empty")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	req.Members, err = parseBulkMembersCSV(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// registerMember register single member row of bulk registration
+func (a *AccountHandler) registerMember(ctx context.Context, req *entity.BulkRegistrationRequest, row int, member entity.BulkRegistrationMember) entity.BulkRegistrationResult {
+	result := entity.BulkRegistrationResult{
+		Row:        row,
+		TerminalID: member.TerminalID,
+	}
+
+	createdAccount, validation, err := a.registerAccount(ctx, &entity.AccountBalance{
+		PartnerID:    req.PartnerID,
+		MerchantID:   req.MerchantID,
+		TerminalID:   member.TerminalID,
+		TerminalName: member.TerminalName,
+		Type:         utilities.AccountTypeRegular,
+	})
+
+	if err != nil {
+		appErr := apperror.From(err)
+		if appErr.Code == apperror.CodeInternal {
+			utilities.Logger(ctx).Error().Err(err).Int("row", row).Str("terminalId", member.TerminalID).Msg("failed to register member")
+		}
+		result.Message = appErr.ClientMessage()
+		result.ErrorCode = string(appErr.Code)
+		if validation != nil {
+			result.Errors = validation
+		}
+		return result
+	}
+
+	result.Success = true
+	result.Message = "registration successful"
+	result.AccountID = createdAccount.ID
+
+	return result
+}
+
+// processBulkRegistration register every member of request in the background and store its progress into job document
+func processBulkRegistration(ctx context.Context, req *entity.BulkRegistrationRequest, jobRepo repository.BulkRegistrationRepository) {
+	defer bulkJobs.Done()
+	handler := NewAccountHandler()
+
+	jobRepo.Entity.Status = utilities.JobStatusProcessing
+	var batch []entity.BulkRegistrationResult
+
+	for idx, member := range req.Members {
+		select {
+		case <-bulkJobsStop:
+			jobRepo.Entity.Status = utilities.JobStatusInterrupted
+			if err := jobRepo.AppendResults(ctx, batch); err != nil {
+				utilities.Logger(ctx).Error().Err(err).Str("jobId", jobRepo.Entity.ID).Msg("failed to store bulk registration progress")
+			}
+			utilities.Logger(ctx).Warn().
+				Str("jobId", jobRepo.Entity.ID).
+				Int("processed", jobRepo.Entity.Processed).
+				Int("total", jobRepo.Entity.Total).
+				Msg("bulk registration job interrupted by shutdown")
+			return
+		default:
+		}
+
+		result := handler.registerMember(ctx, req, idx+1, member)
+		if result.Success {
+			jobRepo.Entity.SuccessCount++
+		} else {
+			jobRepo.Entity.FailedCount++
+		}
+		jobRepo.Entity.Processed++
+		batch = append(batch, result)
+
+		if len(batch) >= bulkRegistrationProgressBatch || idx == len(req.Members)-1 {
+			if jobRepo.Entity.Processed == jobRepo.Entity.Total {
+				jobRepo.Entity.Status = utilities.JobStatusCompleted
+			}
+
+			if err := jobRepo.AppendResults(ctx, batch); err != nil {
+				utilities.Logger(ctx).Error().Err(err).Str("jobId", jobRepo.Entity.ID).Msg("failed to store bulk registration progress")
+			}
+			batch = nil
+		}
+	}
+
+	utilities.Logger(ctx).Info().
+		Str("jobId", jobRepo.Entity.ID).
+		Int("success", jobRepo.Entity.SuccessCount).
+		Int("total", jobRepo.Entity.Total).
+		Msg("bulk registration job finished")
+}
+
+// BulkRegister register members (regular account) of a partner/merchant from json payload or csv upload.
+// rows is processed synchronously, or in the background when async is true.
+func (a *AccountHandler) BulkRegister(c *fiber.Ctx) error {
+	req, err := parseBulkRegistrationRequest(c)
+	if err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err = scopePartner(c, &req.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if validation, err := validator.ValidateRequest(req); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	if len(req.Members) > bulkRegistrationMaxRows {
+		return SendValidationErrResponse(fmt.Sprintf("maximum members per request is %d", bulkRegistrationMaxRows), c)
+	}
+
+	if !req.Async {
+		if len(req.Members) > bulkRegistrationSyncLimit {
+			return SendValidationErrResponse(fmt.Sprintf("more than %d members must be registered with async mode", bulkRegistrationSyncLimit), c)
+		}
+
+		// use dedicated handler, so repository entity is not shared with other request
+		handler := NewAccountHandler()
+		results := make([]entity.BulkRegistrationResult, 0, len(req.Members))
+		success := 0
+		for idx, member := range req.Members {
+			result := handler.registerMember(c.UserContext(), req, idx+1, member)
+			if result.Success {
+				success++
+			}
+			results = append(results, result)
+		}
+
+		return c.Status(200).JSON(entity.Responses{
+			Success: success > 0,
+			Message: fmt.Sprintf("%d/%d member has been successfully registered", success, len(req.Members)),
+			Total:   len(results),
+			Data:    results,
+		})
+	}
+
+	jobRepo := repository.NewBulkRegistrationRepository()
+	jobRepo.Entity = &entity.BulkRegistrationJob{
+		PartnerID:  req.PartnerID,
+		MerchantID: req.MerchantID,
+		Status:     utilities.JobStatusPending,
+		Total:      len(req.Members),
+		CreatedAt:  time.Now().UnixMilli(),
+	}
+	jobRepo.Entity.UpdatedAt = jobRepo.Entity.CreatedAt
+
+	if _, err = jobRepo.Create(c.UserContext()); err != nil {
+		return SendDefaultErrResponse("failed to create bulk registration job, ", err, c)
+	}
+
+	err = c.Status(202).JSON(entity.Responses{
+		Success: true,
+		Message: "bulk registration job has been accepted",
+		Data:    jobRepo.Entity,
+	})
+
+	// job outlives the request, its context only keeps the request logger
+	ctx := utilities.WithFields(utilities.WithLogger(context.Background(), *utilities.ContextLogger(c.UserContext())),
+		map[string]interface{}{"jobId": jobRepo.Entity.ID})
+	bulkJobs.Add(1)
+	go processBulkRegistration(ctx, req, jobRepo)
+
+	return err
+}
+
+// GetBulkRegistrationJob returns progress and a page of row results of async bulk registration, query params: page and size
+func (a *AccountHandler) GetBulkRegistrationJob(c *fiber.Ctx) error {
+	page := int64(c.QueryInt("page", 1))
+	size := int64(c.QueryInt("size", validator.MaxPageSize))
+	if page < 1 || size < 1 || size > validator.MaxPageSize {
+		return SendValidationErrResponse(fmt.Sprintf("page must be greater than 0, and size must be between 1 and %d", validator.MaxPageSize), c)
+	}
+
+	jobRepo := repository.NewBulkRegistrationRepository()
+	job, err := jobRepo.FindByID(c.UserContext(), c.Params("id"))
+	if err == nil && !isPartnerAllowed(c, job.PartnerID) {
+		err = mongo.ErrNoDocuments
+	}
+
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch bulk registration job, ", err, c)
+	}
+
+	results, total, err := jobRepo.FindResults(c.UserContext(), job.ID, page, size)
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch bulk registration results, ", err, c)
+	}
+
+	job.Results = results
+	job.Pagination = &entity.PaginationInfo{
+		PerPage:     size,
+		CurrentPage: page,
+		LastPage:    (total + size - 1) / size,
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "bulk registration job fetched successfully",
+		Data:    job,
+	})
+}
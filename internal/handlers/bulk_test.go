@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStopBulkJobs(t *testing.T) {
+	// running job is waited until its finished
+	finished := make(chan struct{})
+	bulkJobs.Add(1)
+	go func() {
+		defer bulkJobs.Done()
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+	}()
+
+	if err := StopBulkJobs(context.Background()); err != nil {
+		t.Fatalf("got error %v, want stopped", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("StopBulkJobs returned before the job is finished")
+	}
+
+	// running job is interrupted when the context is done
+	bulkJobs.Add(1)
+	go func() {
+		defer bulkJobs.Done()
+		<-bulkJobsStop
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := StopBulkJobs(ctx); err != nil {
+		t.Errorf("got error %v, want interrupted job to be stopped", err)
+	}
+}
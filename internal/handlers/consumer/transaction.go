@@ -1,20 +1,30 @@
 package consumer
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/dw-account-service/internal/kafka/topic"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/dw-account-service/internal/utilities/crypt"
 	"github.com/dw-account-service/internal/utilities/str"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"strconv"
 	"time"
 )
 
+// maxBalanceUpdateAttempts is maximum attempts of balance update when the balance is changed by concurrent transaction
+const maxBalanceUpdateAttempts = 5
+
 type TransactionHandler struct {
 	transactionRepository repository.TransactionRepository
 	accountRepository     repository.AccountRepository
@@ -30,9 +40,11 @@ func NewTransactionHandler() TransactionHandler {
 func modifyBalance(current, amount int64, keys string, transType int) (int64, string) {
 	var last int64
 
-	if transType == utilities.TransTypeTopUp {
+	switch transType {
+	case utilities.TransTypeTopUp, utilities.TransTypeAdjustment:
+		// adjustment amount is signed, negative amount is a debit
 		last = current + amount
-	} else {
+	default:
 		last = current - amount
 	}
 
@@ -48,7 +60,15 @@ func modifyBalance(current, amount int64, keys string, transType int) (int64, st
 	return last, encrypted
 }
 
-func (t *TransactionHandler) doValidation(data *entity.BalanceTransaction) (*entity.BalanceTransaction, error) {
+// fail set result status and error code of failed transaction
+func fail(data *entity.BalanceTransaction, err error) (*entity.BalanceTransaction, error) {
+	appErr := apperror.From(err)
+	data.Status = appErr.TrxStatus()
+	data.ErrorCode = string(appErr.Code)
+	return data, err
+}
+
+func (t *TransactionHandler) doValidation(ctx context.Context, data *entity.BalanceTransaction) (*entity.BalanceTransaction, error) {
 
 	t.accountRepository.Entity.PartnerID = data.PartnerID
 	t.accountRepository.Entity.MerchantID = data.MerchantID
@@ -58,21 +78,19 @@ func (t *TransactionHandler) doValidation(data *entity.BalanceTransaction) (*ent
 		t.accountRepository.Entity.Type = utilities.AccountTypeMerchant
 	}
 
-	account, err := t.accountRepository.FindOne()
+	account, err := t.accountRepository.FindOne(ctx)
 	if err != nil {
 		// invalid account infos
-		data.Status = utilities.TrxStatusInvalidAccount
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return data, errors.New("unable to find account detail with supplied parameters")
+			err = apperror.Wrap(apperror.CodeAccountNotFound, "unable to find account detail with supplied parameters", err)
 		}
-		return data, err
+		return fail(data, err)
 	}
 
 	// validate account is in active status
 	if !account.Active {
-		utilities.Log.Println("| account deactivated, balance update cannot be processed ")
-		data.Status = utilities.TrxStatusInvalidAccount
-		return data, err
+		utilities.Logger(ctx).Warn().Str("accountId", account.ID).Msg("account deactivated, balance update cannot be processed")
+		return fail(data, apperror.ErrAccountDeactivated)
 	}
 
 	t.accountRepository.Entity = account
@@ -80,68 +98,128 @@ func (t *TransactionHandler) doValidation(data *entity.BalanceTransaction) (*ent
 	data.LastBalance = account.LastBalanceNumeric
 
 	if data.TransType == utilities.TransTypeDistribution {
-		memberCount, err2 := t.accountRepository.CountMembers()
+		memberCount, err2 := t.accountRepository.CountMembers(ctx)
 		if err2 != nil {
-			data.Status = utilities.TrxStatusFailed
-			return data, errors.New("failed to get total active members")
+			return fail(data, apperror.Wrap(apperror.CodeInternal, "failed to get total active members", err2))
 		}
 
 		data.TotalAmount = memberCount * data.Items[0].Amount
 		data.Items[0].Qty = int(memberCount)
 	}
 
-	if data.TransType != utilities.TransTypeTopUp && data.LastBalance < data.TotalAmount {
-		data.Status = utilities.TrxStatusInsufficientFund
-		return data, errors.New("insufficient account balance")
+	insufficient := data.LastBalance < data.TotalAmount
+	switch data.TransType {
+	case utilities.TransTypeTopUp:
+		insufficient = false
+	case utilities.TransTypeAdjustment:
+		insufficient = data.LastBalance+data.TotalAmount < 0
+	}
+
+	if insufficient {
+		return fail(data, apperror.ErrInsufficientFunds)
 	}
 
 	return data, nil
 }
 
-func (t *TransactionHandler) DoHandleTransactionRequest(message *sarama.ConsumerMessage) (*entity.BalanceTransaction, error) {
-
+func (t *TransactionHandler) DoHandleTransactionRequest(ctx context.Context, message *sarama.ConsumerMessage) (*entity.BalanceTransaction, error) {
 	var err error
 
 	data := new(entity.BalanceTransaction)
-	err = json.Unmarshal(message.Value, &data)
-	if err != nil {
-		return nil, err
+
+	// reject payload that doesn't match schema of its version header,
+	// the payload is still decoded when possible so the result can be correlated by partner
+	if err = topic.Validate(message.Topic, topic.MessageVersion(message.Headers), message.Value); err != nil {
+		_ = json.Unmarshal(message.Value, data)
+		return fail(data, apperror.Validation(err.Error()))
 	}
 
-	// validate account partner, merchant and terminal
-	data, err = t.doValidation(data)
-	if err != nil {
-		return data, err
+	if err = json.Unmarshal(message.Value, data); err != nil {
+		return fail(data, apperror.Validation(err.Error()))
 	}
 
-	// modify last balance with amount of transaction, based on transType value
-	lb, encLb := modifyBalance(
-		t.accountRepository.Entity.LastBalanceNumeric,
-		data.TotalAmount,
-		t.accountRepository.Entity.SecretKey,
-		data.TransType,
-	)
+	if err = validator.ValidateMessage(data); err != nil {
+		return fail(data, err)
+	}
 
-	data.LastBalance = lb
-	data.LastBalanceEncrypted = encLb
+	return t.Process(ctx, data)
+}
+
+// Process validate and apply balance transaction into its account, together with its balance movement.
+// returned transaction status is set according to the result
+func (t *TransactionHandler) Process(ctx context.Context, data *entity.BalanceTransaction) (*entity.BalanceTransaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionHandler.Process", trace.WithAttributes(
+		attribute.Int("transType", data.TransType),
+		attribute.String("partnerId", data.PartnerID),
+		attribute.String("partnerRefNumber", data.PartnerRefNumber),
+	))
+
+	var err error
+	defer func() {
+		span.SetAttributes(attribute.String("status", data.Status))
+		tracing.End(span, err)
+	}()
+
+	// every log of the transaction is correlated by its account and partner ref number
+	ctx = utilities.WithFields(ctx, map[string]interface{}{
+		"partnerId":        data.PartnerID,
+		"merchantId":       data.MerchantID,
+		"terminalId":       data.TerminalID,
+		"partnerRefNumber": data.PartnerRefNumber,
+	})
+
+	var updatedAccount *entity.AccountBalance
+	for attempt := 1; ; attempt++ {
+		// validate account partner, merchant and terminal
+		data, err = t.doValidation(ctx, data)
+		if err != nil {
+			return data, err
+		}
+
+		// modify last balance with amount of transaction, based on transType value
+		lb, encLb := modifyBalance(
+			t.accountRepository.Entity.LastBalanceNumeric,
+			data.TotalAmount,
+			t.accountRepository.Entity.SecretKey,
+			data.TransType,
+		)
+
+		data.LastBalance = lb
+		data.LastBalanceEncrypted = encLb
+
+		// transaction date and receipt number are recorded in the balance movement
+		trxDate := time.Now()
+		data.TransDateNumeric = trxDate.UnixMilli()
+		data.TransDate = trxDate.Format("20060102150405")
+		data.ReceiptNumber = str.GenerateReceiptNumber(data.TransType, "")
+
+		// update account last balance and record its balance movement for account statement,
+		// balance changed by concurrent transaction is validated again with its current balance
+		t.transactionRepository.Entity = data
+		updatedAccount, err = t.transactionRepository.UpdateBalance(ctx, t.accountRepository.Entity.LastBalanceNumeric)
+		if errors.Is(err, repository.ErrBalanceChanged) && attempt < maxBalanceUpdateAttempts {
+			utilities.Logger(ctx).Debug().Int("attempt", attempt).Msg("balance changed by concurrent transaction, retrying")
+			continue
+		}
+
+		break
+	}
 
-	// update account last balance
-	t.transactionRepository.Entity = data
-	updatedAccount, err := t.transactionRepository.UpdateBalance()
 	if err != nil {
-		utilities.Log.Println("| failed to update balance, with err: ", err.Error())
-		data.Status = utilities.TrxStatusFailed
-		return data, err
+		utilities.Logger(ctx).Error().Err(err).Msg("failed to update balance")
+
+		// balance is not changed
+		data.TransDate = ""
+		data.TransDateNumeric = 0
+		data.ReceiptNumber = ""
+		data.LastBalance = t.accountRepository.Entity.LastBalanceNumeric
+		return fail(data, err)
 	}
 
 	// return entity.BalanceTransaction data with status Success ("00")
-	trxDate := time.Now()
-	data.TransDateNumeric = trxDate.UnixMilli()
-	data.TransDate = trxDate.Format("20060102150405")
-
-	data.ReceiptNumber = str.GenerateReceiptNumber(data.TransType, "")
 	data.LastBalance = updatedAccount.LastBalanceNumeric
 	data.Status = utilities.TrxStatusSuccess
+	data.ErrorCode = ""
 
 	return data, nil
 }
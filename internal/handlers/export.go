@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/utilities/xlsx"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+var exportColumns = []interface{}{
+	"accountId", "uniqueId", "partnerId", "merchantId", "terminalId", "terminalName",
+	"type", "status", "lastBalance", "createdAt", "updatedAt",
+}
+
+type exportWriter interface {
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+// csvFormulaPrefixes are leading characters of a cell that spreadsheet applications evaluate as formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// WriteRow write values as csv record, text value which can be evaluated as formula is prefixed with single quote
+func (c *csvExportWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		text, ok := v.(string)
+		if !ok {
+			record[i] = fmt.Sprint(v)
+			continue
+		}
+
+		if text != "" && strings.ContainsRune(csvFormulaPrefixes, rune(text[0])) {
+			text = "'" + text
+		}
+		record[i] = text
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatExportTime(unixMilli int64) string {
+	if unixMilli == 0 {
+		return ""
+	}
+	return time.UnixMilli(unixMilli).Format("2006-01-02 15:04:05")
+}
+
+func accountExportRow(account *entity.AccountBalance) []interface{} {
+	status := utilities.AccountStatusActive
+	if !account.Active {
+		status = utilities.AccountStatusDeactivated
+	}
+
+	return []interface{}{
+		account.ID,
+		account.UniqueID,
+		account.PartnerID,
+		account.MerchantID,
+		account.TerminalID,
+		account.TerminalName,
+		account.Type,
+		status,
+		account.LastBalanceNumeric,
+		formatExportTime(account.CreatedAt),
+		formatExportTime(account.UpdatedAt),
+	}
+}
+
+// streamAccounts write every account matching the filter into response body as csv or xlsx file,
+// documents are read from cursor and written row by row, so it's never loaded entirely into memory.
+func (a *AccountHandler) streamAccounts(c *fiber.Ctx, format, sheetName, fileNamePrefix string, filter bson.D) error {
+	fileName := fmt.Sprintf("%s-%s.%s", fileNamePrefix, time.Now().Format("20060102150405"), format)
+
+	switch format {
+	case ExportFormatCSV:
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	case ExportFormatXLSX:
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	accountRepo := repository.NewAccountRepository()
+	// response is streamed after the handler returned, request context can't be used by the writer
+	logger := utilities.ContextLogger(c.UserContext()).With().Str("fileName", fileName).Logger()
+	c.Status(200).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var (
+			writer exportWriter
+			err    error
+		)
+
+		if format == ExportFormatXLSX {
+			writer, err = xlsx.NewStreamWriter(w, sheetName)
+			if err != nil {
+				logger.Error().Err(err).Msg("failed to create xlsx writer")
+				return
+			}
+		} else {
+			writer = &csvExportWriter{w: csv.NewWriter(w)}
+		}
+
+		ctx, cancel := context.WithTimeout(utilities.WithLogger(context.Background(), logger), 30*time.Minute)
+		defer cancel()
+
+		total := 0
+		_ = writer.WriteRow(exportColumns...)
+		err = accountRepo.Iterate(ctx, filter, func(account *entity.AccountBalance) error {
+			if err := writer.WriteRow(accountExportRow(account)...); err != nil {
+				return err
+			}
+
+			total++
+			// flush periodically, so client start receiving data
+			if total%1000 == 0 {
+				return w.Flush()
+			}
+			return nil
+		}, options.Find().
+			SetProjection(bson.D{{"secretKey", 0}, {"lastBalance", 0}}).
+			SetSort(bson.D{{"createdAt", 1}}))
+
+		if err != nil {
+			logger.Error().Err(err).Int("rows", total).Msg("export interrupted")
+		}
+
+		if err = writer.Close(); err != nil {
+			logger.Error().Err(err).Msg("failed to finalize export")
+		}
+	})
+
+	return nil
+}
+
+// parseExportRequest parse PaginatedAccountRequest and format query param (csv|xlsx) for export endpoint
+func parseExportRequest(c *fiber.Ctx) (*entity.PaginatedAccountRequest, string, bool, error) {
+	format := strings.ToLower(c.Query("format", ExportFormatCSV))
+	if format != ExportFormatCSV && format != ExportFormatXLSX {
+		return nil, "", false, apperror.Validation(fmt.Sprintf("invalid export format. its only accept %s or %s", ExportFormatCSV, ExportFormatXLSX))
+	}
+
+	req := new(entity.PaginatedAccountRequest)
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, req); err != nil {
+			return nil, "", false, apperror.Validation(err.Error())
+		}
+	}
+
+	req.Status = strings.ToLower(req.Status)
+	validStatus := map[string]interface{}{"all": 0, "active": 1, "deactivated": 2}
+	if req.Status != "" {
+		if _, ok := validStatus[req.Status]; !ok {
+			return nil, "", false, apperror.Validation("invalid status value. its only accept all, active or deactivated")
+		}
+	}
+
+	isPeriod := req.Periods.Start != "" || req.Periods.End != ""
+	if isPeriod {
+		if err := parsePeriods(&req.Periods); err != nil {
+			return nil, "", false, err
+		}
+	}
+
+	if err := scopePartner(c, &req.PartnerID); err != nil {
+		return nil, "", false, err
+	}
+
+	return req, format, isPeriod, nil
+}
+
+// ExportAccounts export all accounts matching PaginatedAccountRequest filter as csv or xlsx (query param: format)
+func (a *AccountHandler) ExportAccounts(c *fiber.Ctx) error {
+	req, format, isPeriod, err := parseExportRequest(c)
+	if errors.Is(err, errPartnerScope) {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if err != nil {
+		return SendErrResponse(err, nil, c)
+	}
+
+	return a.streamAccounts(c, format, "accounts", "accounts", repository.GetAccountListFilter(req, isPeriod))
+}
+
+// ExportMerchantMembers export all members of a merchant as csv or xlsx (query param: format)
+func (a *AccountHandler) ExportMerchantMembers(c *fiber.Ctx) error {
+	req, format, isPeriod, err := parseExportRequest(c)
+	if errors.Is(err, errPartnerScope) {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if err != nil {
+		return SendErrResponse(err, nil, c)
+	}
+
+	if req.PartnerID == "" {
+		return SendValidationErrResponse("partnerId cannot be empty", c)
+	}
+
+	if req.MerchantID == "" {
+		return SendValidationErrResponse("merchantId cannot be empty", c)
+	}
+
+	req.Type = utilities.AccountTypeRegular
+	fileNamePrefix := "members-" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, req.MerchantID)
+
+	return a.streamAccounts(c, format, "members", fileNamePrefix, repository.GetAccountListFilter(req, isPeriod))
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestCSVExportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &csvExportWriter{w: csv.NewWriter(&buf)}
+
+	err := w.WriteRow("=HYPERLINK(\"http://evil\")", "+62811", "-1+1", "@SUM(A1)", "\tTAB", "\rCR", "Toko A", "", int64(-5000), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := csv.NewReader(&buf).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// text is escaped, numeric value is written as is
+	want := []string{"'=HYPERLINK(\"http://evil\")", "'+62811", "'-1+1", "'@SUM(A1)", "'\tTAB", "'\rCR", "Toko A", "", "-5000", "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
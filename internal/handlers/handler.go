@@ -1,53 +1,153 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/middleware"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
 )
 
+// SendErrResponse send error response with http status and error code of the error
+func SendErrResponse(err error, data interface{}, c *fiber.Ctx) error {
+	appErr := apperror.From(err)
+	logInternalErr(appErr, c)
+
+	return c.Status(appErr.Status()).JSON(entity.Responses{
+		Success:   false,
+		Message:   appErr.ClientMessage(),
+		ErrorCode: string(appErr.Code),
+		Data:      data,
+	})
+}
+
+// SendValidationErrResponse send bad request response of invalid request payload
+func SendValidationErrResponse(message string, c *fiber.Ctx) error {
+	return SendErrResponse(apperror.Validation(message), nil, c)
+}
+
+// SendInvalidRequestResponse send bad request response with message of every invalid field in errors array
+func SendInvalidRequestResponse(err error, validation interface{}, c *fiber.Ctx) error {
+	return SendErrResponse(err, map[string]interface{}{"errors": validation}, c)
+}
+
 func SendDefaultErrResponse(prefix string, err error, c *fiber.Ctx) error {
 
-	utilities.Log.Println(err)
+	appErr := apperror.From(err)
 
-	errMsg := err.Error()
-	if errors.Is(err, context.DeadlineExceeded) {
-		errMsg = "context deadline exceeded"
+	message := strings.TrimSuffix(strings.TrimSpace(prefix), ",")
+	if message == "" {
+		message = "request failed"
 	}
+	utilities.Logger(c.UserContext()).Error().Err(err).Str("errorCode", string(appErr.Code)).Msg(message)
 
-	if errors.Is(err, mongo.ErrClientDisconnected) {
-		errMsg = "client disconnected"
-	}
+	return c.Status(appErr.Status()).JSON(entity.Responses{
+		Success:   false,
+		Message:   fmt.Sprintf("%s%s", prefix, appErr.ClientMessage()),
+		ErrorCode: string(appErr.Code),
+		Data:      nil,
+	})
+}
+
+func SendDefaultPaginationErrResponse(prefix string, err error, c *fiber.Ctx) error {
+
+	appErr := apperror.From(err)
+	logInternalErr(appErr, c)
+
+	return c.Status(appErr.Status()).JSON(entity.PaginatedResponse{
+		Success:   false,
+		Message:   fmt.Sprintf("%s%s", prefix, appErr.ClientMessage()),
+		ErrorCode: string(appErr.Code),
+		Data:      entity.PaginatedDetailResponse{},
+	})
+}
 
-	if mongo.IsNetworkError(err) {
-		errMsg = "error connection occurred"
+// logInternalErr log cause of internal error, its message is not returned to the client
+func logInternalErr(appErr *apperror.Error, c *fiber.Ctx) {
+	if appErr.Code == apperror.CodeInternal {
+		utilities.Logger(c.UserContext()).Error().Err(appErr.Unwrap()).Str("errorCode", string(appErr.Code)).Msg(appErr.Error())
 	}
+}
 
+// accountErr returns account not found error when account lookup doesn't find any document
+func accountErr(err error) error {
 	if errors.Is(err, mongo.ErrNoDocuments) {
-		errMsg = "entity not found"
+		return apperror.Wrap(apperror.CodeAccountNotFound, "account not found", err)
 	}
+	return err
+}
 
-	return c.Status(500).JSON(entity.Responses{
-		Success: false,
-		Message: fmt.Sprintf("%s%s", prefix, errMsg),
-		Data:    nil,
-	})
+// parsePeriods converts periods start and end (YYYYMMDD) into StartDate (00:00:00) and EndDate (23:59:59)
+func parsePeriods(periods *entity.PeriodsRequest) error {
+	var err error
+
+	periods.StartDate, err = time.ParseInLocation(
+		"20060102150405",
+		fmt.Sprintf("%s%s", periods.Start, "000000"),
+		time.Now().Location(),
+	)
+	if err != nil {
+		return apperror.New(apperror.CodeInvalidPeriod, "invalid start periods")
+	}
+
+	periods.EndDate, err = time.ParseInLocation(
+		"20060102150405",
+		fmt.Sprintf("%s%s", periods.End, "235959"),
+		time.Now().Location(),
+	)
+	if err != nil {
+		return apperror.New(apperror.CodeInvalidPeriod, "invalid end periods")
+	}
+
+	if periods.EndDate.Before(periods.StartDate) {
+		return apperror.New(apperror.CodeInvalidPeriod, "end period cannot be less than start period")
+	}
+
+	return nil
 }
 
-func SendDefaultPaginationErrResponse(prefix string, err error, c *fiber.Ctx) error {
+var errPartnerScope = apperror.New(apperror.CodeForbidden, "partnerId does not match with the authenticated partner")
 
-	errMsg := err.Error()
-	if errors.Is(err, context.DeadlineExceeded) {
-		errMsg = "context deadline exceeded"
+// scopePartner restrict partnerId of request to the authenticated partner.
+// empty partnerId is filled with the authenticated partner, and other partnerId is rejected
+func scopePartner(c *fiber.Ctx, partnerID *string) error {
+	authenticated := middleware.PartnerID(c)
+	if authenticated == "" {
+		return nil
 	}
 
-	return c.Status(500).JSON(entity.PaginatedResponse{
-		Success: false,
-		Message: fmt.Sprintf("%s%s", prefix, errMsg),
-		Data:    entity.PaginatedDetailResponse{},
-	})
+	if *partnerID == "" {
+		*partnerID = authenticated
+		return nil
+	}
+
+	if *partnerID != authenticated {
+		return errPartnerScope
+	}
+
+	return nil
+}
+
+// isPartnerAllowed check if the document partnerId can be accessed by authenticated partner
+func isPartnerAllowed(c *fiber.Ctx, partnerID string) bool {
+	authenticated := middleware.PartnerID(c)
+	return authenticated == "" || authenticated == partnerID
+}
+
+func SendPartnerScopeErrResponse(err error, c *fiber.Ctx) error {
+	return SendErrResponse(err, nil, c)
+}
+
+// LocalsRequestBody is request body parsed by the handler, so the documented request type can be checked against it
+const LocalsRequestBody = "requestBody"
+
+// parseBody parse request body into out, and keep out as the request body of the handler
+func parseBody(c *fiber.Ctx, out interface{}) error {
+	c.Locals(LocalsRequestBody, out)
+	return c.BodyParser(out)
 }
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestScopePartner(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticated string
+		partnerID     string
+		want          string
+		err           error
+	}{
+		{"not partner scoped", "", "MDL", "MDL", nil},
+		{"not partner scoped without partner", "", "", "", nil},
+		{"empty partner is filled", "MDL", "", "MDL", nil},
+		{"same partner", "MDL", "MDL", "MDL", nil},
+		{"other partner", "MDL", "OTHER", "OTHER", errPartnerScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.authenticated != "" {
+					c.Locals(middleware.LocalsPartnerID, tt.authenticated)
+				}
+
+				partnerID := tt.partnerID
+				err := scopePartner(c, &partnerID)
+				if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+					t.Errorf("got error %v, want %v", err, tt.err)
+				}
+				if partnerID != tt.want {
+					t.Errorf("got partnerId %q, want %q", partnerID, tt.want)
+				}
+				if allowed := isPartnerAllowed(c, "MDL"); allowed != (tt.authenticated == "" || tt.authenticated == "MDL") {
+					t.Errorf("isPartnerAllowed(MDL) = %v for authenticated partner %q", allowed, tt.authenticated)
+				}
+
+				return nil
+			})
+
+			if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestSendDefaultErrResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"internal error", errors.New("connection(10.0.0.5:27017) socket was unexpectedly closed"), fiber.StatusInternalServerError, "failed to fetch account, internal server error"},
+		{"validation error", apperror.Validation("partnerId cannot be empty"), fiber.StatusBadRequest, "failed to fetch account, partnerId cannot be empty"},
+		{"not found", mongo.ErrNoDocuments, fiber.StatusNotFound, "failed to fetch account, entity not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				return SendDefaultErrResponse("failed to fetch account, ", tt.err, c)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			var body entity.Responses
+			if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("invalid response body: %v", err)
+			}
+
+			if resp.StatusCode != tt.status || body.Message != tt.message {
+				t.Errorf("got status %d message %q, want %d %q", resp.StatusCode, body.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestInvalidID(t *testing.T) {
+	accountHandler := NewAccountHandler()
+	adminHandler := NewAdminHandler()
+	webhookHandler := NewWebhookHandler()
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.LocalsSubject, "admin@example.com")
+		return c.Next()
+	})
+	app.Get("/account/register/bulk/:id", accountHandler.GetBulkRegistrationJob)
+	app.Post("/admin/adjustment/:id/approve", adminHandler.ApproveAdjustment)
+	app.Post("/webhook/deliveries/:id/redeliver", webhookHandler.Redeliver)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{fiber.MethodGet, "/account/register/bulk/abc"},
+		{fiber.MethodPost, "/admin/adjustment/abc/approve"},
+		{fiber.MethodPost, "/webhook/deliveries/abc/redeliver"},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+
+		var body entity.Responses
+		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("invalid response body: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusBadRequest || !strings.HasSuffix(body.Message, "invalid id") {
+			t.Errorf("%s %s: got status %d message %q, want %d invalid id", tt.method, tt.path, resp.StatusCode, body.Message, fiber.StatusBadRequest)
+		}
+	}
+}
+
+func TestBulkRegistrationJobPagination(t *testing.T) {
+	accountHandler := NewAccountHandler()
+
+	app := fiber.New()
+	app.Get("/account/register/bulk/:id", accountHandler.GetBulkRegistrationJob)
+
+	for _, query := range []string{"page=0", "size=0", "size=101"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/account/register/bulk/abc?"+query, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("%s: got status %d, want %d", query, resp.StatusCode, fiber.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/reconciliation"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReconciliationHandler struct{}
+
+func NewReconciliationHandler() ReconciliationHandler {
+	return ReconciliationHandler{}
+}
+
+// Upload reconcile partner settlement file (multipart form field: file) against processed top-up transactions.
+// form fields: partnerId (required), start & end (optional, YYYYMMDD)
+func (r *ReconciliationHandler) Upload(c *fiber.Ctx) error {
+	partnerID := c.FormValue("partnerId")
+	if err := scopePartner(c, &partnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if partnerID == "" {
+		return SendValidationErrResponse("partnerId cannot be empty", c)
+	}
+
+	var periods entity.PeriodsRequest
+	if c.FormValue("start") != "" || c.FormValue("end") != "" {
+		periods.Start = c.FormValue("start")
+		periods.End = c.FormValue("end")
+		if err := parsePeriods(&periods); err != nil {
+			return SendErrResponse(err, nil, c)
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return SendValidationErrResponse("settlement file cannot be empty", c)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return SendDefaultErrResponse("cannot read settlement file, ", err, c)
+	}
+	defer file.Close()
+
+	records, err := reconciliation.ParseSettlementFile(file)
+	if err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	report, err := reconciliation.Reconcile(c.UserContext(), partnerID, records, periods.StartDate, periods.EndDate)
+	if err != nil {
+		return SendDefaultErrResponse("failed to reconcile settlement file, ", err, c)
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "reconciliation successfully processed",
+		Data:    report,
+	})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/consumer"
+	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"github.com/dw-account-service/internal/webhook"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+// transactionRequestHash identify request payload of a partner ref number, so a used ref number cannot be reused for other transaction
+func transactionRequestHash(trx *entity.BalanceTransaction) string {
+	return crypt.SHA256Hex([]byte(fmt.Sprintf("%s|%s|%d", trx.MerchantID, trx.TerminalID, trx.TotalAmount)))
+}
+
+// sendTransactionResponse returns 200 for successful transaction, or the transaction with http status of its error code
+func sendTransactionResponse(c *fiber.Ctx, trx *entity.BalanceTransaction, message string) error {
+	if trx.Status == utilities.TrxStatusSuccess {
+		return c.Status(fiber.StatusOK).JSON(entity.Responses{
+			Success: true,
+			Message: message,
+			Data:    trx,
+		})
+	}
+
+	code := apperror.Code(trx.ErrorCode)
+	if code == "" {
+		// failed transaction stored before error code is recorded
+		code = apperror.CodeValidation
+	}
+
+	return SendErrResponse(apperror.New(code, message), trx, c)
+}
+
+// appliedTransaction returns successful transaction of the reclaimed request from its balance movement,
+// it returns nil when the request hasn't been applied
+func appliedTransaction(c *fiber.Ctx, request *entity.TransactionRequest) (*entity.BalanceTransaction, error) {
+	statementRepo := repository.NewStatementRepository()
+	movement, err := statementRepo.FindByPartnerRef(
+		c.UserContext(),
+		request.PartnerID,
+		request.PartnerRefNumber,
+		request.TransType,
+		time.UnixMilli(request.CreatedAt),
+	)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entity.BalanceTransaction{
+		TransDate:        movement.TransDate,
+		TransDateNumeric: movement.TransDateNumeric,
+		ReferenceNo:      movement.ReferenceNo,
+		ReceiptNumber:    movement.ReceiptNumber,
+		LastBalance:      movement.BalanceAfter,
+		Status:           utilities.TrxStatusSuccess,
+		TransType:        movement.TransType,
+		PartnerTransDate: movement.PartnerTransDate,
+		PartnerRefNumber: movement.PartnerRefNumber,
+		PartnerID:        movement.PartnerID,
+		MerchantID:       movement.MerchantID,
+		TerminalID:       movement.TerminalID,
+		TotalAmount:      movement.Credit + movement.Debit,
+	}, nil
+}
+
+// Transaction process topup or payment request synchronously, using the same validation and balance update of consumed transaction.
+// request is idempotent on partnerRefNumber, processed transaction is returned as is for the same partnerRefNumber.
+func (b *BalanceHandler) Transaction(c *fiber.Ctx, transType int) error {
+	payload := new(entity.BalanceTransaction)
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	payload.TransType = transType
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	// partnerRefNumber is the idempotency key of rest api transaction
+	if payload.PartnerRefNumber == "" {
+		return SendValidationErrResponse("partnerRefNumber cannot be empty", c)
+	}
+
+	// fields below is set by the service
+	payload.ID = ""
+	payload.Status = ""
+	payload.ErrorCode = ""
+	payload.ReceiptNumber = ""
+	payload.LastBalance = 0
+	payload.TransDate = ""
+	payload.TransDateNumeric = 0
+	if payload.RequestDetail.Origin == "" {
+		payload.RequestDetail.Origin = "rest-api"
+	}
+	if payload.RequestDetail.Timestamp == "" {
+		payload.RequestDetail.Timestamp = time.Now().Format("20060102150405")
+	}
+
+	requestRepo := repository.NewTransactionRequestRepository()
+	requestRepo.Entity = &entity.TransactionRequest{
+		PartnerID:        payload.PartnerID,
+		PartnerRefNumber: payload.PartnerRefNumber,
+		TransType:        transType,
+		RequestHash:      transactionRequestHash(payload),
+	}
+
+	reserved, existing, err := requestRepo.Reserve(c.UserContext())
+	if err != nil {
+		return SendDefaultErrResponse("failed to validate partnerRefNumber, ", err, c)
+	}
+
+	if !reserved {
+		if existing.RequestHash != requestRepo.Entity.RequestHash {
+			return SendErrResponse(apperror.New(apperror.CodeRefNumberReused, "partnerRefNumber has been used for different transaction"), nil, c)
+		}
+
+		if existing.Status != repository.TransactionRequestCompleted || existing.Transaction == nil {
+			return SendErrResponse(apperror.New(apperror.CodeConflict, "transaction with current partnerRefNumber is still being processed"), nil, c)
+		}
+
+		c.Set(HeaderIdempotentReplayed, "true")
+		return sendTransactionResponse(c, existing.Transaction, "transaction has been processed before")
+	}
+
+	// reclaimed request might have been applied before its process is stopped
+	if existing != nil {
+		applied, err := appliedTransaction(c, existing)
+		if err != nil {
+			return SendDefaultErrResponse("failed to validate partnerRefNumber, ", err, c)
+		}
+
+		if applied != nil {
+			if errComplete := requestRepo.Complete(c.UserContext(), applied); errComplete != nil {
+				utilities.Logger(c.UserContext()).Error().Err(errComplete).
+					Str("partnerId", payload.PartnerID).
+					Str("partnerRefNumber", payload.PartnerRefNumber).
+					Msg("failed to store transaction result")
+			}
+
+			c.Set(HeaderIdempotentReplayed, "true")
+			return sendTransactionResponse(c, applied, "transaction has been processed before")
+		}
+	}
+
+	handler := consumer.NewTransactionHandler()
+	trx, err := handler.Process(c.UserContext(), payload)
+	if err != nil {
+		utilities.Logger(c.UserContext()).Error().Err(err).
+			Str("partnerId", payload.PartnerID).
+			Str("partnerRefNumber", payload.PartnerRefNumber).
+			Msg("failed to process transaction")
+
+		// internal failure is not stored, so it can be retried with the same partnerRefNumber
+		if trx.Status == utilities.TrxStatusFailed {
+			if errRelease := requestRepo.Release(c.UserContext()); errRelease != nil {
+				utilities.Logger(c.UserContext()).Error().Err(errRelease).
+					Str("partnerId", payload.PartnerID).
+					Str("partnerRefNumber", payload.PartnerRefNumber).
+					Msg("failed to release partnerRefNumber")
+			}
+			return sendTransactionResponse(c, trx, "failed to process transaction, "+apperror.From(err).ClientMessage())
+		}
+	}
+
+	if errComplete := requestRepo.Complete(c.UserContext(), trx); errComplete != nil {
+		utilities.Logger(c.UserContext()).Error().Err(errComplete).
+			Str("partnerId", payload.PartnerID).
+			Str("partnerRefNumber", payload.PartnerRefNumber).
+			Msg("failed to store transaction result")
+	}
+
+	webhook.Dispatch(c.UserContext(), trx)
+
+	if err != nil {
+		return sendTransactionResponse(c, trx, apperror.From(err).ClientMessage())
+	}
+
+	return sendTransactionResponse(c, trx, "transaction successfully processed")
+}
@@ -2,45 +2,138 @@ package validator
 
 import (
 	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/utilities"
+	govalidator "github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
 )
 
-func ValidateRequest(payload interface{}) (interface{}, error) {
-	var msg []string
+// MaxPageSize is maximum size of paginated request
+const MaxPageSize = 100
 
-	switch p := payload.(type) {
+var errValidation = apperror.Validation("request validation status failed")
 
-	case *entity.AccountBalance:
+// messages override default message of field validation, key: field.tag
+var messages = map[string]string{
+	"type.required": "type cannot be empty. eg: 1 (regular) or 2 (merchant)",
+	"type.oneof":    "unsupported account type. only: 1 (regular) or 2 (merchant)",
+}
 
-		if p.Type == 0 {
-			msg = append(msg, "type cannot be empty. eg: 1 (regular) or 2 (merchant)")
-			return msg, errors.New("request validation status failed")
-		}
+var validate = newValidate()
 
-		if p.Type > 2 {
-			msg = append(msg, "unsupported account type. only: 1 (regular) or 2 (merchant)")
-			return msg, errors.New("request validation status failed")
-		}
+func newValidate() *govalidator.Validate {
+	v := govalidator.New()
 
-		// if non-merchant account type
-		if p.Type == 1 && p.TerminalID == "" {
-			msg = append(msg, "terminalId cannot be empty.")
+	// use json field name in error message
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
 		}
+		return name
+	})
 
-		if p.PartnerID == "" {
-			msg = append(msg, "partnerId cannot be empty.")
-		}
+	v.RegisterStructValidation(validateBalanceTransaction, entity.BalanceTransaction{})
+
+	return v
+}
 
-		if p.MerchantID == "" {
-			msg = append(msg, "merchantId cannot be empty.")
+// validateBalanceTransaction validate rules that depend on transaction type
+func validateBalanceTransaction(sl govalidator.StructLevel) {
+	trx := sl.Current().Interface().(entity.BalanceTransaction)
+
+	// distribution amount is taken from its first item
+	if trx.TransType != utilities.TransTypeDistribution {
+		return
+	}
+
+	if len(trx.Items) == 0 {
+		sl.ReportError(trx.Items, "items", "Items", "required", "")
+	}
+
+	// distributed amount must be positive, item amount of other transaction may be zero.
+	// negative amount is already reported by gte rule of the item
+	for i, item := range trx.Items {
+		if item.Amount == 0 {
+			sl.ReportError(item.Amount, fmt.Sprintf("items[%d].amount", i), fmt.Sprintf("Items[%d].Amount", i), "gt", "0")
 		}
+	}
+}
+
+// ValidateRequest validate payload with its `validate` struct tags,
+// it returns message of every invalid field when validation failed
+func ValidateRequest(payload interface{}) (interface{}, error) {
+	err := validate.Struct(payload)
+	if err == nil {
+		return nil, nil
+	}
+
+	var fieldErrors govalidator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return []string{err.Error()}, errValidation
+	}
+
+	var msg []string
+	for _, fieldError := range fieldErrors {
+		msg = append(msg, message(fieldError))
+	}
+
+	return msg, errValidation
+}
+
+// ValidateMessage validate consumed message payload, the error contains message of every invalid field
+func ValidateMessage(payload interface{}) error {
+	msg, err := ValidateRequest(payload)
+	if err != nil {
+		return apperror.Validation(fmt.Sprintf("%s: %s", err.Error(), strings.Join(msg.([]string), " ")))
+	}
+	return nil
+}
 
-	default:
+func message(fieldError govalidator.FieldError) string {
+	// namespace without the struct name, e.g. items[0].amount
+	field := fieldError.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
 	}
 
-	if len(msg) > 0 {
-		return msg, errors.New("request validation status failed")
+	if msg, ok := messages[fmt.Sprintf("%s.%s", field, fieldError.Tag())]; ok {
+		return msg
+	}
+
+	param := fieldError.Param()
+	switch fieldError.Tag() {
+	case "required", "required_if", "required_unless":
+		return fmt.Sprintf("%s cannot be empty.", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s.", field, strings.ReplaceAll(param, " ", ", "))
+	case "min", "gte":
+		switch fieldError.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Array:
+			if param == "1" {
+				return fmt.Sprintf("%s cannot be empty.", field)
+			}
+			return fmt.Sprintf("%s must contain at least %s items.", field, param)
+		case reflect.String:
+			return fmt.Sprintf("%s must be at least %s characters.", field, param)
+		}
+		return fmt.Sprintf("%s must be greater than or equal to %s.", field, param)
+	case "max", "lte":
+		switch fieldError.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return fmt.Sprintf("%s must contain at most %s items.", field, param)
+		case reflect.String:
+			return fmt.Sprintf("%s must be at most %s characters.", field, param)
+		}
+		return fmt.Sprintf("%s must be less than or equal to %s.", field, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s.", field, param)
+	case "url", "http_url":
+		return fmt.Sprintf("%s must be a valid url.", field)
 	}
-	return msg, nil
 
+	return fmt.Sprintf("%s is invalid.", field)
 }
@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/utilities"
+)
+
+func transaction(transType int, totalAmount int64, amounts ...int64) *entity.BalanceTransaction {
+	trx := &entity.BalanceTransaction{
+		TransType:   transType,
+		PartnerID:   "MDL",
+		MerchantID:  "M001",
+		TotalAmount: totalAmount,
+	}
+	for _, amount := range amounts {
+		trx.Items = append(trx.Items, entity.TransactionItem{Name: "item", Amount: amount})
+	}
+	return trx
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    []string
+	}{
+		{"valid topup", transaction(utilities.TransTypeTopUp, 10000), nil},
+		{"topup item may be zero", transaction(utilities.TransTypeTopUp, 10000, 0), nil},
+		{"topup without amount", transaction(utilities.TransTypeTopUp, 0), []string{"totalAmount cannot be empty."}},
+		{"negative payment", transaction(utilities.TransTypePayment, -1), []string{"totalAmount must be greater than or equal to 0."}},
+		{"unsupported type", transaction(utilities.TransTypeAdjustment, 10000), []string{"transType must be one of: 1, 2, 3."}},
+		{"valid distribution", transaction(utilities.TransTypeDistribution, 0, 5000), nil},
+		{"distribution without items", transaction(utilities.TransTypeDistribution, 0), []string{"items cannot be empty."}},
+		{"distribution with zero item", transaction(utilities.TransTypeDistribution, 0, 5000, 0), []string{"items[1].amount must be greater than 0."}},
+		{"distribution with negative item", transaction(utilities.TransTypeDistribution, 0, -5000), []string{"items[0].amount must be greater than or equal to 0."}},
+		{"missing partner", &entity.BalanceTransaction{TransType: utilities.TransTypeTopUp, MerchantID: "M001", TotalAmount: 1}, []string{"partnerId cannot be empty."}},
+		{"overridden message", &entity.AccountBalance{PartnerID: "MDL", MerchantID: "M001", Type: 3}, []string{"unsupported account type. only: 1 (regular) or 2 (merchant)"}},
+		{"required_if", &entity.AccountBalance{PartnerID: "MDL", MerchantID: "M001", Type: utilities.AccountTypeRegular}, []string{"terminalId cannot be empty."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateRequest(tt.payload)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("got error %v with %v, want valid", err, got)
+				}
+				return
+			}
+
+			if !errors.Is(err, apperror.Validation("")) {
+				t.Fatalf("got error %v, want validation error", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	if err := ValidateMessage(transaction(utilities.TransTypeDistribution, 0, 5000)); err != nil {
+		t.Errorf("got error %v, want valid", err)
+	}
+
+	err := ValidateMessage(transaction(utilities.TransTypeDistribution, 0))
+	want := "request validation status failed: items cannot be empty."
+	if !errors.Is(err, apperror.Validation("")) || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
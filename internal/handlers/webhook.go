@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/handlers/validator"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"github.com/dw-account-service/internal/webhook"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+type WebhookHandler struct {
+	repo         repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+}
+
+func NewWebhookHandler() WebhookHandler {
+	return WebhookHandler{
+		repo:         repository.NewWebhookRepository(),
+		deliveryRepo: repository.NewWebhookDeliveryRepository(),
+	}
+}
+
+// SetWebhook create or update partner webhook endpoint, signing secret is generated on creation or when rotateSecret is true
+func (w *WebhookHandler) SetWebhook(c *fiber.Ctx) error {
+	payload := new(entity.PartnerWebhookRequest)
+	if err := parseBody(c, payload); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	if err := scopePartner(c, &payload.PartnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if validation, err := validator.ValidateRequest(payload); err != nil {
+		return SendInvalidRequestResponse(err, validation, c)
+	}
+
+	if err := webhook.ValidateURL(c.UserContext(), payload.URL); err != nil {
+		return SendValidationErrResponse(err.Error(), c)
+	}
+
+	repo := repository.NewWebhookRepository()
+	current, err := repo.FindByPartnerID(c.UserContext(), payload.PartnerID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return SendDefaultErrResponse("failed to fetch partner webhook, ", err, c)
+	}
+
+	now := time.Now().UnixMilli()
+	repo.Entity = &entity.PartnerWebhook{
+		PartnerID: payload.PartnerID,
+		URL:       payload.URL,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if current != nil {
+		repo.Entity.Secret = current.Secret
+		repo.Entity.CreatedAt = current.CreatedAt
+	}
+
+	if payload.Active != nil {
+		repo.Entity.Active = *payload.Active
+	}
+
+	if repo.Entity.Secret == "" || payload.RotateSecret {
+		if repo.Entity.Secret, err = crypt.GenerateSigningSecret(); err != nil {
+			return SendDefaultErrResponse("failed to generate webhook secret, ", err, c)
+		}
+	}
+
+	if err = repo.Upsert(c.UserContext()); err != nil {
+		return SendDefaultErrResponse("failed to store partner webhook, ", err, c)
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "partner webhook successfully stored",
+		Data:    repo.Entity,
+	})
+}
+
+// GetWebhook returns partner webhook endpoint configuration, query param: partnerId
+func (w *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	partnerID := c.Query("partnerId")
+	if err := scopePartner(c, &partnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	if partnerID == "" {
+		return SendValidationErrResponse("partnerId cannot be empty", c)
+	}
+
+	webhookConfig, err := w.repo.FindByPartnerID(c.UserContext(), partnerID)
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch partner webhook, ", err, c)
+	}
+
+	// secret is only shown when its generated
+	webhookConfig.Secret = ""
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "partner webhook successfully fetched",
+		Data:    webhookConfig,
+	})
+}
+
+// GetFailedDeliveries returns callback failed deliveries, query params: partnerId, page and size
+func (w *WebhookHandler) GetFailedDeliveries(c *fiber.Ctx) error {
+	partnerID := c.Query("partnerId")
+	if err := scopePartner(c, &partnerID); err != nil {
+		return SendPartnerScopeErrResponse(err, c)
+	}
+
+	page := int64(c.QueryInt("page", 1))
+	size := int64(c.QueryInt("size", 10))
+	if page < 1 || size < 1 || size > validator.MaxPageSize {
+		return SendDefaultPaginationErrResponse("", apperror.Validation(fmt.Sprintf("page must be greater than 0, and size must be between 1 and %d", validator.MaxPageSize)), c)
+	}
+
+	deliveries, total, err := w.deliveryRepo.FindFailed(c.UserContext(), partnerID, page, size)
+	if err != nil {
+		return SendDefaultPaginationErrResponse("", err, c)
+	}
+
+	lastPage := (total + size - 1) / size
+	return c.Status(200).JSON(entity.PaginatedResponse{
+		Success: true,
+		Message: "failed webhook deliveries successfully fetched",
+		Data: entity.PaginatedDetailResponse{
+			Result: deliveries,
+			Total:  total,
+			Pagination: entity.PaginationInfo{
+				PerPage:     size,
+				CurrentPage: page,
+				LastPage:    lastPage,
+			},
+		},
+	})
+}
+
+// Redeliver queue callback failed delivery once more, failed attempt is retried with backoff
+func (w *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	current, err := w.deliveryRepo.FindByID(c.UserContext(), c.Params("id"))
+	if err == nil && !isPartnerAllowed(c, current.PartnerID) {
+		err = mongo.ErrNoDocuments
+	}
+
+	if err != nil {
+		return SendDefaultErrResponse("failed to fetch webhook delivery, ", err, c)
+	}
+
+	delivery, err := webhook.Redeliver(c.UserContext(), current.ID)
+	if err != nil {
+		if errors.Is(err, webhook.ErrNotRedeliverable) {
+			return SendValidationErrResponse(err.Error(), c)
+		}
+		return SendDefaultErrResponse("failed to redeliver webhook, ", err, c)
+	}
+
+	return c.Status(200).JSON(entity.Responses{
+		Success: true,
+		Message: "webhook has been queued for redelivery",
+		Data:    delivery,
+	})
+}
@@ -0,0 +1,137 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/db"
+	"github.com/dw-account-service/internal/kafka"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check is status of a dependency
+type Check struct {
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Latency string      `json:"latency,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Report is readiness status of the service, it is up when every dependency is up
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+type checkFunc func(ctx context.Context) Check
+
+func timeout() time.Duration {
+	if configs.MainConfig.Health.TimeoutSeconds > 0 {
+		return time.Duration(configs.MainConfig.Health.TimeoutSeconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+// Live returns status of the process, it doesn't check any dependency
+func Live() Report {
+	return Report{Status: StatusUp}
+}
+
+// Ready check every dependency concurrently, kafka producer and consumer are checked only when its started
+func Ready(ctx context.Context) Report {
+	checks := map[string]checkFunc{
+		"mongodb": checkMongo,
+	}
+
+	if started, _ := kafka.ProducerStatus(); started {
+		checks["kafkaProducer"] = checkProducer
+	}
+
+	if started, _, _ := kafka.ConsumerStatus(); started {
+		checks["kafkaConsumer"] = checkConsumer
+	}
+
+	report := Report{Status: StatusUp, Checks: map[string]Check{}}
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check checkFunc) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout())
+			defer cancel()
+
+			start := time.Now()
+			result := check(checkCtx)
+			result.Latency = time.Since(start).String()
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func down(err error) Check {
+	return Check{Status: StatusDown, Error: err.Error()}
+}
+
+func checkMongo(ctx context.Context) Check {
+	if db.Mongo.Client == nil {
+		return Check{Status: StatusDown, Error: "client is not connected"}
+	}
+
+	if err := db.Mongo.Client.Ping(ctx, readpref.Primary()); err != nil {
+		return down(err)
+	}
+
+	return Check{Status: StatusUp}
+}
+
+func checkProducer(context.Context) Check {
+	if _, err := kafka.ProducerStatus(); err != nil {
+		return down(err)
+	}
+	return Check{Status: StatusUp}
+}
+
+func checkConsumer(context.Context) Check {
+	_, lags, err := kafka.ConsumerStatus()
+	if err != nil {
+		check := down(err)
+		check.Details = lags
+		return check
+	}
+
+	maxLag := configs.MainConfig.Health.MaxConsumerLag
+	if maxLag > 0 {
+		for _, lag := range lags {
+			if lag.Lag > maxLag {
+				return Check{
+					Status:  StatusDown,
+					Error:   fmt.Sprintf("lag of %s/%d is %d, exceeds maximum lag %d", lag.Topic, lag.Partition, lag.Lag, maxLag),
+					Details: lags,
+				}
+			}
+		}
+	}
+
+	return Check{Status: StatusUp, Details: lags}
+}
@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/kafka/topic"
+	"github.com/dw-account-service/internal/tracing"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// checkpointLease is how long claimed checkpoint is not resumed by other worker, it's extended after each member
+const checkpointLease = time.Minute
+
+// errResumeStopped is returned when resuming checkpoint is stopped by shutdown
+var errResumeStopped = errors.New("resuming balance distribution is stopped")
+
+// resumeDistributions continue balance distributions which were halted by shutdown, until no checkpoint is left or stop is done.
+// checkpoints are claimed one at a time, so they can be resumed by multiple workers
+func resumeDistributions(stop context.Context) {
+	checkpointRepo := repository.NewCheckpointRepository()
+	logger := utilities.Component("consumer")
+
+	for stop.Err() == nil {
+		checkpoint, err := checkpointRepo.Claim(context.Background(), checkpointLease)
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				logger.Error().Err(err).Msg("failed to claim balance distribution checkpoint")
+			}
+			return
+		}
+
+		ctx := utilities.WithLogger(context.Background(), logger.With().
+			Str("checkpointId", checkpoint.ID).
+			Str("partnerId", checkpoint.PartnerID).
+			Str("merchantId", checkpoint.MerchantID).
+			Str("partnerRefNumber", checkpoint.PartnerRefNumber).
+			Logger())
+
+		if err = resumeCheckpoint(ctx, stop, checkpoint); err != nil {
+			if !errors.Is(err, errResumeStopped) {
+				utilities.Logger(ctx).Error().Err(err).Msg("failed to resume balance distribution")
+			}
+			if err = checkpointRepo.Release(ctx, checkpoint.ID); err != nil {
+				utilities.Logger(ctx).Error().Err(err).Msg("failed to release balance distribution checkpoint")
+			}
+			return
+		}
+	}
+}
+
+// resumeCheckpoint credit remaining members of the checkpoint, then remove it.
+// member which has been credited before the checkpoint is updated is not credited again
+func resumeCheckpoint(ctx context.Context, stop context.Context, checkpoint *entity.DistributionCheckpoint) error {
+	ctx, span := tracing.Start(ctx, "ResumeBalanceDistribution",
+		trace.WithAttributes(attribute.String("partnerId", checkpoint.PartnerID), attribute.String("merchantId", checkpoint.MerchantID)))
+	defer span.End()
+
+	data := &entity.BalanceTransaction{
+		TransType:        utilities.TransTypeDistribution,
+		Status:           utilities.TrxStatusSuccess,
+		PartnerID:        checkpoint.PartnerID,
+		MerchantID:       checkpoint.MerchantID,
+		PartnerRefNumber: checkpoint.PartnerRefNumber,
+		PartnerTransDate: checkpoint.PartnerTransDate,
+		ReferenceNo:      checkpoint.ReferenceNo,
+		Items:            []entity.TransactionItem{{Amount: checkpoint.Amount}},
+	}
+
+	checkpointRepo := repository.NewCheckpointRepository()
+	accountRepo := repository.NewAccountRepository()
+	statementRepo := repository.NewStatementRepository()
+
+	credited := 0
+	for _, memberID := range checkpoint.RemainingMembers {
+		select {
+		case <-stop.Done():
+			return errResumeStopped
+		case <-halt:
+			return errResumeStopped
+		default:
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(memberID)
+		if err != nil {
+			return err
+		}
+
+		member, err := accountRepo.FindByID(ctx, objectID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		switch {
+		case member == nil || !member.Active:
+			utilities.Logger(ctx).Warn().Str("accountId", memberID).Msg("member is not found or deactivated, balance distribution is skipped")
+		default:
+			done, err := statementRepo.HasDistributionCredit(ctx, member, data.PartnerRefNumber, data.ReferenceNo)
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+
+			result := creditMember(ctx, *member, data, 0)
+			if result.Err != nil {
+				utilities.Logger(ctx).Error().Err(result.Err).Str("accountId", memberID).Msg("error on update member balance")
+				break
+			}
+
+			payload, _ := json.Marshal(result.Data)
+			if err = ProduceMsg(ctx, topic.DistributionResultMembers, payload); err != nil {
+				utilities.Logger(ctx).Error().Err(err).
+					Str("accountId", memberID).
+					Str("receiptNumber", result.Data.ReceiptNumber).
+					Msg("cannot produce member distribution result")
+			}
+
+			// send member result to the member partner webhook
+			webhook.Dispatch(ctx, &result.Data)
+			credited++
+		}
+
+		if err = checkpointRepo.RemoveMember(ctx, checkpoint.ID, memberID, checkpointLease); err != nil {
+			return err
+		}
+	}
+
+	if err := checkpointRepo.Delete(ctx, checkpoint.ID); err != nil {
+		return err
+	}
+
+	utilities.Logger(ctx).Info().
+		Int("updated", credited).
+		Int("remaining", len(checkpoint.RemainingMembers)).
+		Msg("halted balance distribution has been resumed")
+
+	return nil
+}
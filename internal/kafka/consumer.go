@@ -2,12 +2,24 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/Shopify/sarama"
 	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
-	"log"
 	"strings"
-	"sync"
+	"time"
+)
+
+// checkpointTimeout is additional time for halted balance distributions to checkpoint, after shutdown timeout is reached
+const checkpointTimeout = 5 * time.Second
+
+var (
+	consumerGroup  sarama.ConsumerGroup
+	consumerCancel context.CancelFunc
+	// consumerDone is closed when consumer loop has been stopped, and every claimed message has been handled
+	consumerDone chan struct{}
 )
 
 type MessageConsumer struct {
@@ -20,13 +32,29 @@ func (consumer *MessageConsumer) ConsumeClaim(session sarama.ConsumerGroupSessio
 	// Do not move the code below to a goroutine.
 	// The `ConsumeClaim` itself is called within a goroutine, see:
 	// https://github.com/Shopify/sarama/blob/main/consumer_group.go#L27-L29
+	trackClaim(claim)
+
 	for {
 		select {
-		case message := <-claim.Messages():
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+
+			// consumer is stopping, the message is not marked so it's consumed again by the next session
+			if session.Context().Err() != nil {
+				return nil
+			}
+
 			//log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
-			HandleMessages(message)
+			// continue trace of the producer, processing is not cancelled by rebalance of the session
+			if err := HandleMessages(tracing.ExtractMessage(context.Background(), message), message); err != nil {
+				// message is not marked, the session is restarted and the message is consumed again
+				return fmt.Errorf("message at offset %d cannot be rejected: %s", message.Offset, err.Error())
+			}
 
 			session.MarkMessage(message, "")
+			markConsumed(message)
 
 		// Should return when `session.Context()` is done.
 		// If not, will raise `ErrRebalanceInProgress` or `read tcp <ip>:<port>: i/o timeout` when kafka rebalance. see:
@@ -39,12 +67,14 @@ func (consumer *MessageConsumer) ConsumeClaim(session sarama.ConsumerGroupSessio
 
 // Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
 func (consumer *MessageConsumer) Cleanup(sarama.ConsumerGroupSession) error {
+	setConsumerSession(false)
 	return nil
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim
 func (consumer *MessageConsumer) Setup(sarama.ConsumerGroupSession) error {
 	// Mark the consumer as ready
+	setConsumerSession(true)
 	close(consumer.ready)
 	return nil
 }
@@ -52,7 +82,10 @@ func (consumer *MessageConsumer) Setup(sarama.ConsumerGroupSession) error {
 func initConsumer() (sarama.ConsumerGroup, MessageConsumer, error) {
 	splitBrokers := strings.Split(configs.MainConfig.Kafka.Brokers, ",")
 
-	conf := configs.NewSaramaConfig()
+	conf, err := configs.NewSaramaConfig()
+	if err != nil {
+		return nil, MessageConsumer{}, err
+	}
 
 	switch configs.MainConfig.Kafka.Consumer.Assignor {
 	case "sticky":
@@ -62,7 +95,7 @@ func initConsumer() (sarama.ConsumerGroup, MessageConsumer, error) {
 	case "range":
 		conf.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.BalanceStrategyRange}
 	default:
-		log.Panicf("Unrecognized consumer group partition assignor: %s", configs.MainConfig.Kafka.Consumer.Assignor)
+		return nil, MessageConsumer{}, fmt.Errorf("unrecognized consumer group partition assignor: %s", configs.MainConfig.Kafka.Consumer.Assignor)
 	}
 
 	if configs.MainConfig.Kafka.Consumer.Oldest {
@@ -79,10 +112,10 @@ func initConsumer() (sarama.ConsumerGroup, MessageConsumer, error) {
 	//ctx, cancel := context.WithCancel(context.Background())
 	client, err := sarama.NewConsumerGroup(splitBrokers, configs.MainConfig.Kafka.Consumer.ConsumerGroupName, conf)
 	if err != nil {
-		utilities.Log.Panicf("Error creating consumer group client: %v", err)
+		return nil, c, fmt.Errorf("error creating consumer group client: %s", err.Error())
 	}
 
-	return client, c, err
+	return client, c, nil
 
 }
 
@@ -92,32 +125,75 @@ func StartConsumer() {
 
 	client, subscriber, err := initConsumer()
 	if err != nil {
-		utilities.Log.Fatalln(err)
+		utilities.Component("consumer").Fatal().Err(err).Msg("error on consumer initialization")
 	}
 
 	topicMsg := strings.Split(configs.MainConfig.Kafka.Consumer.ConsumerTopics, ",")
 
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
+	setConsumerStarted()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumerGroup, consumerCancel, consumerDone = client, cancel, make(chan struct{})
+
+	resumed := make(chan struct{})
+	go func() {
+		defer close(resumed)
+		resumeDistributions(ctx)
+	}()
+
 	go func() {
-		defer wg.Done()
+		defer close(consumerDone)
+		// consumer is stopped after resuming halted distributions is stopped
+		defer func() { <-resumed }()
+
 		for {
 
-			if err = client.Consume(context.Background(), topicMsg, &subscriber); err != nil {
-				utilities.Log.Printf("| Error from consumer: %v", err)
+			if err = client.Consume(ctx, topicMsg, &subscriber); err != nil {
+				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+					return
+				}
+				utilities.Component("consumer").Error().Err(err).Msg("error from consumer")
+				setConsumerError(err)
 				//log.Panicf("Error from consumer: %v", err)
 			}
 
 			// check if context was cancelled, signaling that the consumer should stop
-			//if ctx.Err() != nil {
-			//	return
-			//}
+			if ctx.Err() != nil {
+				return
+			}
 			subscriber.ready = make(chan bool)
 		}
 	}()
 	// wait till the consumer has been set up
-	<-subscriber.ready
+	select {
+	case <-subscriber.ready:
+	case <-consumerDone:
+		return
+	}
+
+	utilities.Component("consumer").Info().Msg("consumer up and running")
+}
+
+// StopConsumer stop consuming new messages and wait until in-flight message has been handled, then leave the consumer group.
+// balance distributions still running when the context is done are halted, and their remaining members are checkpointed.
+// checkpointed distributions are resumed when the consumer is started again
+func StopConsumer(ctx context.Context) error {
+	if consumerCancel == nil {
+		return nil
+	}
+	consumerCancel()
+
+	select {
+	case <-consumerDone:
+	case <-ctx.Done():
+		haltDistributions()
+
+		select {
+		case <-consumerDone:
+		case <-time.After(checkpointTimeout):
+			return errors.New("consumer is not stopped, in-flight message is abandoned")
+		}
+	}
 
-	utilities.Log.Println("| consumer >> up and running!...")
-	wg.Done()
+	return consumerGroup.Close()
 }
@@ -1,26 +1,54 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/db/repository"
 	"github.com/dw-account-service/internal/kafka/topic"
+	"github.com/dw-account-service/internal/metrics"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/dw-account-service/internal/utilities/crypt"
 	"github.com/dw-account-service/internal/utilities/str"
+	"github.com/dw-account-service/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"strconv"
 	"sync"
 	"time"
 )
 
+// maxBalanceUpdateAttempts is maximum attempts of member balance update when the balance is changed by concurrent transaction
+const maxBalanceUpdateAttempts = 5
+
 type DistributionTrx struct {
 	accountRepo     repository.AccountRepository
 	transactionRepo repository.TransactionRepository
 }
 
-func DoBalanceDistribution(data *entity.BalanceTransaction) error {
+var (
+	// halt is closed when shutdown timeout is reached, running distributions stop dispatching remaining members
+	halt     = make(chan struct{})
+	haltOnce sync.Once
+)
+
+func haltDistributions() {
+	haltOnce.Do(func() {
+		close(halt)
+	})
+}
+
+func DoBalanceDistribution(ctx context.Context, data *entity.BalanceTransaction) error {
+	ctx, span := tracing.Start(ctx, "DoBalanceDistribution",
+		trace.WithAttributes(attribute.String("partnerId", data.PartnerID), attribute.String("merchantId", data.MerchantID)))
+	defer span.End()
+
 	accountRepo := repository.NewAccountRepository()
+	start := time.Now()
 
 	params := new(entity.PaginatedAccountRequest)
 	params.PartnerID = data.PartnerID
@@ -28,13 +56,13 @@ func DoBalanceDistribution(data *entity.BalanceTransaction) error {
 	params.Type = utilities.AccountTypeRegular
 	params.Status = utilities.AccountStatusActive
 
-	members, err := accountRepo.FindMembers(params)
+	members, err := accountRepo.FindMembers(ctx, params)
 	if err != nil {
 		return err
 	}
 
 	// pipeline 1: job distribution
-	chanJobIndex := generateWorkerData(members)
+	chanJobIndex, chanRemaining := generateWorkerData(members)
 
 	// pipeline 2: update balance
 	workerCount := 10
@@ -44,23 +72,22 @@ func DoBalanceDistribution(data *entity.BalanceTransaction) error {
 		workerCount = 5
 	}
 
-	chanUpdateResult := doBatchUpdateBalance(chanJobIndex, workerCount, data)
+	chanUpdateResult := doBatchUpdateBalance(ctx, chanJobIndex, workerCount, data)
 
 	totalJob := 0
 	successJob := 0
 	successProduce := 0
 	for result := range chanUpdateResult {
 		if result.Err != nil {
-			utilities.Log.Println("| error on update balance on account id: ", result.Data.ID)
+			utilities.Logger(ctx).Error().Err(result.Err).Str("accountId", result.Data.ID).Msg("error on update member balance")
 		} else {
 			payload, _ := json.Marshal(result.Data)
-			err = ProduceMsg(topic.DistributionResultMembers, payload)
+			err = ProduceMsg(ctx, topic.DistributionResultMembers, payload)
 			if err != nil {
-				utilities.Log.Printf("| cannot produce message (%s) for topic: %s, with err: %s",
-					result.Data.ReceiptNumber,
-					topic.DistributionResultMembers,
-					err.Error(),
-				)
+				utilities.Logger(ctx).Error().Err(err).
+					Str("accountId", result.Data.ID).
+					Str("receiptNumber", result.Data.ReceiptNumber).
+					Msg("cannot produce member distribution result")
 			} else {
 				//utilities.Log.Printf("| account balance with id: %s, has been successfully processed with receipt number: %s\n",
 				//	result.Data.ID,
@@ -68,18 +95,146 @@ func DoBalanceDistribution(data *entity.BalanceTransaction) error {
 				//)
 				successProduce++
 			}
+
+			// send member result to the member partner webhook
+			webhook.Dispatch(ctx, &result.Data)
 			successJob++
 		}
 		totalJob++
 	}
 
-	utilities.Log.Printf("| %d/%d of member balances has been successfully updated", successJob, totalJob)
-	utilities.Log.Printf("| %d/%d of success messages has been successfully produced", successProduce, successJob)
+	utilities.Logger(ctx).Info().
+		Int("updated", successJob).
+		Int("total", totalJob).
+		Int("produced", successProduce).
+		Msg("member balances has been updated")
+	metrics.ObserveDistribution(len(members), time.Since(start))
+
+	// members that haven't been dispatched when the distribution is halted
+	if remaining := <-chanRemaining; len(remaining) > 0 {
+		checkpointDistribution(ctx, data, len(members), remaining)
+	}
 
 	return nil
 }
 
-func doBatchUpdateBalance(chanIn <-chan entity.AccountBalance, workerCount int, data *entity.BalanceTransaction) <-chan entity.BalanceDistributionInfo {
+// checkpointDistribution store remaining members of halted distribution, so it can be continued by resumeDistributions
+// without crediting members that already received the balance
+func checkpointDistribution(ctx context.Context, data *entity.BalanceTransaction, total int, remaining []entity.AccountBalance) {
+	memberIDs := make([]string, 0, len(remaining))
+	for _, member := range remaining {
+		memberIDs = append(memberIDs, member.ID)
+	}
+
+	checkpointRepo := repository.NewCheckpointRepository()
+	checkpointRepo.Entity = &entity.DistributionCheckpoint{
+		PartnerID:        data.PartnerID,
+		MerchantID:       data.MerchantID,
+		PartnerRefNumber: data.PartnerRefNumber,
+		ReferenceNo:      data.ReferenceNo,
+		PartnerTransDate: data.PartnerTransDate,
+		Amount:           data.Items[0].Amount,
+		TotalMembers:     total,
+		RemainingMembers: memberIDs,
+		CreatedAt:        time.Now().UnixMilli(),
+	}
+
+	id, err := checkpointRepo.Create(ctx)
+	if err != nil {
+		utilities.Logger(ctx).Error().Err(err).
+			Strs("remainingMembers", memberIDs).
+			Msg("failed to store checkpoint of halted balance distribution")
+		return
+	}
+
+	utilities.Logger(ctx).Warn().
+		Str("checkpointId", id).
+		Int("remaining", len(memberIDs)).
+		Msg("balance distribution halted by shutdown, remaining members has been checkpointed")
+}
+
+// creditMember add distribution amount into the member balance, member balance that has been changed by concurrent
+// transaction is fetched again before its credited
+func creditMember(ctx context.Context, member entity.AccountBalance, data *entity.BalanceTransaction, workerIdx int) entity.BalanceDistributionInfo {
+	var result entity.BalanceDistributionInfo
+
+	for attempt := 1; ; attempt++ {
+		transactionRepo := repository.NewTransactionRepository()
+		currentBalance := member.LastBalanceNumeric
+
+		// update balance
+		member.LastBalanceNumeric += data.Items[0].Amount
+		encrypted, err := crypt.Encrypt(
+			[]byte(member.SecretKey),
+			fmt.Sprintf("%016s", strconv.FormatInt(member.LastBalanceNumeric, 10)),
+		)
+		if err != nil {
+			encrypted = "-"
+		}
+		member.LastBalance = encrypted
+
+		// populate chanOut Data
+		var items []entity.TransactionItem
+		items = append(items, entity.TransactionItem{
+			Name:   "Receiving Balance From: " + member.PartnerID + "-" + member.MerchantID,
+			Amount: data.Items[0].Amount,
+			Qty:    1,
+		})
+
+		trxDate := time.Now()
+		result = entity.BalanceDistributionInfo{
+			Data: entity.BalanceTransaction{
+				TransDate:            trxDate.Format("20060102150405"),
+				TransDateNumeric:     trxDate.UnixMilli(),
+				ReferenceNo:          data.ReferenceNo,
+				ReceiptNumber:        str.GenerateReceiptNumber(data.TransType, ""),
+				LastBalance:          member.LastBalanceNumeric,
+				LastBalanceEncrypted: encrypted,
+				Status:               data.Status,
+				TransType:            data.TransType,
+				PartnerTransDate:     data.PartnerTransDate,
+				PartnerRefNumber:     data.PartnerRefNumber,
+				PartnerID:            member.PartnerID,
+				MerchantID:           member.MerchantID,
+				TerminalID:           member.TerminalID,
+				TerminalName:         member.TerminalName,
+				TotalAmount:          data.Items[0].Amount,
+				Items:                items,
+				CreatedAt:            trxDate.UnixMilli(),
+				UpdatedAt:            trxDate.UnixMilli(),
+			},
+			WorkerIndex: workerIdx,
+		}
+
+		// update member balance together with its balance movement
+		transactionRepo.Entity = &result.Data
+		account, err := transactionRepo.UpdateBalance(ctx, currentBalance)
+		if err == nil {
+			result.Data.LastBalance = account.LastBalanceNumeric
+			return result
+		}
+
+		if errors.Is(err, repository.ErrBalanceChanged) && attempt < maxBalanceUpdateAttempts {
+			var current *entity.AccountBalance
+			objectID, errFind := primitive.ObjectIDFromHex(member.ID)
+			if errFind == nil {
+				accountRepo := repository.NewAccountRepository()
+				current, errFind = accountRepo.FindByID(ctx, objectID)
+			}
+
+			if errFind == nil {
+				member = *current
+				continue
+			}
+			err = errFind
+		}
+
+		result.Err = err
+		return result
+	}
+}
+
+func doBatchUpdateBalance(ctx context.Context, chanIn <-chan entity.AccountBalance, workerCount int, data *entity.BalanceTransaction) <-chan entity.BalanceDistributionInfo {
 	chanOut := make(chan entity.BalanceDistributionInfo)
 
 	wgUpdateBalance := new(sync.WaitGroup)
@@ -89,59 +244,7 @@ func doBatchUpdateBalance(chanIn <-chan entity.AccountBalance, workerCount int,
 		for workerIdx := 0; workerIdx < workerCount; workerIdx++ {
 			go func(idx int) {
 				for accountBalance := range chanIn {
-					transactionRepo := repository.NewTransactionRepository()
-
-					// update balance
-					accountBalance.LastBalanceNumeric += data.Items[0].Amount
-					encrypted, err := crypt.Encrypt(
-						[]byte(accountBalance.SecretKey),
-						fmt.Sprintf("%016s", strconv.FormatInt(accountBalance.LastBalanceNumeric, 10)),
-					)
-					if err != nil {
-						encrypted = "-"
-					}
-					accountBalance.LastBalance = encrypted
-
-					transactionRepo.Entity.MerchantID = accountBalance.MerchantID
-					transactionRepo.Entity.PartnerID = accountBalance.PartnerID
-					transactionRepo.Entity.TerminalID = accountBalance.TerminalID
-					transactionRepo.Entity.LastBalance = accountBalance.LastBalanceNumeric
-					transactionRepo.Entity.LastBalanceEncrypted = encrypted
-
-					trxDate := time.Now()
-					account, err := transactionRepo.UpdateBalance()
-
-					// populate chanOut Data
-					var items []entity.TransactionItem
-					items = append(items, entity.TransactionItem{
-						Name:   "Receiving Balance From: " + account.PartnerID + "-" + account.MerchantID,
-						Amount: data.Items[0].Amount,
-						Qty:    1,
-					})
-
-					chanOut <- entity.BalanceDistributionInfo{
-						Data: entity.BalanceTransaction{
-							TransDate:        trxDate.Format("20060102150405"),
-							TransDateNumeric: trxDate.UnixMilli(),
-							ReferenceNo:      data.ReferenceNo,
-							ReceiptNumber:    str.GenerateReceiptNumber(data.TransType, ""),
-							LastBalance:      account.LastBalanceNumeric,
-							Status:           data.Status,
-							TransType:        data.TransType,
-							PartnerTransDate: data.PartnerTransDate,
-							PartnerRefNumber: data.PartnerRefNumber,
-							PartnerID:        account.PartnerID,
-							MerchantID:       account.MerchantID,
-							TerminalID:       account.TerminalID,
-							TerminalName:     account.TerminalName,
-							TotalAmount:      data.Items[0].Amount,
-							Items:            items,
-							CreatedAt:        trxDate.UnixMilli(),
-							UpdatedAt:        trxDate.UnixMilli(),
-						},
-						WorkerIndex: idx,
-						Err:         err,
-					}
+					chanOut <- creditMember(ctx, accountBalance, data, idx)
 				}
 				wgUpdateBalance.Done()
 			}(workerIdx)
@@ -156,16 +259,33 @@ func doBatchUpdateBalance(chanIn <-chan entity.AccountBalance, workerCount int,
 	return chanOut
 }
 
-func generateWorkerData(members []entity.AccountBalance) <-chan entity.AccountBalance {
+// generateWorkerData dispatch members to update balance workers until every member is dispatched or distributions are halted,
+// then send the members that haven't been dispatched into the second channel
+func generateWorkerData(members []entity.AccountBalance) (<-chan entity.AccountBalance, <-chan []entity.AccountBalance) {
 	chanOut := make(chan entity.AccountBalance)
+	chanRemaining := make(chan []entity.AccountBalance, 1)
 
 	go func() {
-		for _, member := range members {
-			chanOut <- member
+		defer close(chanOut)
+
+		for idx, member := range members {
+			select {
+			case <-halt:
+				chanRemaining <- members[idx:]
+				return
+			default:
+			}
+
+			select {
+			case chanOut <- member:
+			case <-halt:
+				chanRemaining <- members[idx:]
+				return
+			}
 		}
 
-		close(chanOut)
+		chanRemaining <- nil
 	}()
 
-	return chanOut
+	return chanOut, chanRemaining
 }
@@ -1,25 +1,49 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/dw-account-service/internal/apperror"
 	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/handlers/consumer"
 	"github.com/dw-account-service/internal/kafka/topic"
+	"github.com/dw-account-service/internal/metrics"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/webhook"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 	"time"
 )
 
-// HandleMessages contain function/logic that will be executed depends on topic name
-func HandleMessages(message *sarama.ConsumerMessage) {
+// dlqAttempts is maximum attempts of producing rejected message into dead letter topic
+const dlqAttempts = 3
+
+// dlqRetryDelay is delay before the second attempt, doubled on every failed attempt
+var dlqRetryDelay = time.Second
+
+// HandleMessages contain function/logic that will be executed depends on topic name.
+// it returns error when the message cannot be rejected into dead letter topic, so its offset must not be marked
+func HandleMessages(ctx context.Context, message *sarama.ConsumerMessage) error {
 	var (
 		handler              = consumer.NewTransactionHandler()
 		trx                  = new(entity.BalanceTransaction)
 		err                  error
 		resultTopicMsg, pMsg string
+		start                = time.Now()
+		outcome              = metrics.OutcomeSuccess
 	)
 
-	utilities.Log.SetPrefix("[CONSUMER] ")
+	// every log of the message is correlated by its topic partition offset and correlation id
+	ctx = utilities.WithLogger(ctx, utilities.Component("consumer").With().
+		Str("topic", message.Topic).
+		Int32("partition", message.Partition).
+		Int64("offset", message.Offset).
+		Str("correlationId", topic.CorrelationID(message)).
+		Logger())
 
 	switch message.Topic {
 	case topic.TopUpRequest:
@@ -32,38 +56,114 @@ func HandleMessages(message *sarama.ConsumerMessage) {
 		resultTopicMsg = topic.DistributionResult
 		pMsg = "balance distribution"
 	default:
-		utilities.Log.Println("| unknown topic message")
-		return
+		utilities.Logger(ctx).Warn().Msg("unknown topic message")
+		return nil
 	}
 
-	trx, err = handler.DoHandleTransactionRequest(message)
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("%s process", message.Topic),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingSourceName(message.Topic),
+			semconv.MessagingKafkaSourcePartition(int(message.Partition)),
+			semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+		),
+	)
+	defer span.End()
+
+	trx, err = handler.DoHandleTransactionRequest(ctx, message)
+
+	ctx = utilities.WithFields(ctx, map[string]interface{}{
+		"partnerId":        trx.PartnerID,
+		"merchantId":       trx.MerchantID,
+		"terminalId":       trx.TerminalID,
+		"partnerRefNumber": trx.PartnerRefNumber,
+	})
+
 	if err != nil {
-		utilities.Log.Printf("| failed to process consumed message for topic: %s, with err: %s\n",
-			message.Topic,
-			err.Error())
+		utilities.Logger(ctx).Error().Err(err).Msg("failed to process consumed message")
+
+		// invalid message is kept in dead letter topic, its result is still produced with invalid params status
+		outcome = metrics.OutcomeFailed
+		if errors.Is(err, apperror.Validation("")) {
+			outcome = metrics.OutcomeRejected
+			if errReject := rejectMessage(ctx, message, err); errReject != nil {
+				// result is produced when the message is consumed again
+				return errReject
+			}
+		}
 	} else {
-		utilities.Log.Printf("| %s with RefNo: %s, has been successfully processed with receipt number: %s\n",
-			pMsg,
-			trx.ReferenceNo,
-			trx.ReceiptNumber,
-		)
+		utilities.Logger(ctx).Info().
+			Str("referenceNo", trx.ReferenceNo).
+			Str("receiptNumber", trx.ReceiptNumber).
+			Msgf("%s has been successfully processed", pMsg)
 	}
 
 	payload, _ := json.Marshal(trx)
-	err = ProduceMsg(resultTopicMsg, payload)
+	err = ProduceMsg(ctx, resultTopicMsg, payload)
 	if err != nil {
-		utilities.Log.Println("| cannot produce message for topic: ", message.Topic, ", with err: ", err.Error())
+		utilities.Logger(ctx).Error().Err(err).Str("resultTopic", resultTopicMsg).Msg("cannot produce result message")
 	}
 
+	metrics.ObserveMessage(message.Topic, outcome, time.Since(start))
+
+	// send result to partner webhook
+	webhook.Dispatch(ctx, trx)
+
 	// Do Balance Distribution among members
 	if trx.TransType == utilities.TransTypeDistribution && trx.Status == utilities.TrxStatusSuccess {
 		start := time.Now()
-		utilities.Log.Println("| starting merchant balance distribution ... ")
-		err = DoBalanceDistribution(trx)
+		utilities.Logger(ctx).Info().Msg("starting merchant balance distribution")
+		err = DoBalanceDistribution(ctx, trx)
 		if err != nil {
-			utilities.Log.Println("| error occurred: ", err.Error())
+			utilities.Logger(ctx).Error().Err(err).Msg("balance distribution error")
+		}
+		utilities.Logger(ctx).Info().Dur("duration", time.Since(start)).Msg("balance distribution finished")
+	}
+
+	return nil
+}
+
+// rejectMessage produce invalid message as is into dead letter topic of its topic, with the rejection reason header.
+// producing is retried with backoff, it returns the last error when every attempt is failed
+func rejectMessage(ctx context.Context, message *sarama.ConsumerMessage, reason error) error {
+	headers := []sarama.RecordHeader{
+		{Key: []byte(topic.HeaderDLQReason), Value: []byte(reason.Error())},
+		{Key: []byte(topic.HeaderDLQTopic), Value: []byte(message.Topic)},
+	}
+	for _, header := range message.Headers {
+		if header != nil {
+			headers = append(headers, *header)
+		}
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   topic.DLQ(message.Topic),
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	tracing.InjectMessage(ctx, msg)
+
+	var err error
+	delay := dlqRetryDelay
+	for attempt := 1; attempt <= dlqAttempts; attempt++ {
+		if _, _, err = Producer.SendMessage(msg); err == nil {
+			return nil
+		}
+
+		utilities.Logger(ctx).Error().Err(err).
+			Str("dlqTopic", topic.DLQ(message.Topic)).
+			Int("attempt", attempt).
+			Msg("failed to reject message")
+		metrics.ProducerFailed(topic.DLQ(message.Topic))
+
+		if attempt < dlqAttempts {
+			time.Sleep(delay)
+			delay *= 2
 		}
-		utilities.Log.Println("| balance distribution finished in", time.Since(start).Seconds(), "seconds")
 	}
 
+	return err
 }
@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/dw-account-service/internal/kafka/topic"
+)
+
+func TestRejectMessage(t *testing.T) {
+	errBroker := errors.New("broker not available")
+
+	tests := []struct {
+		name    string
+		results []error
+		wantErr bool
+	}{
+		{"produced", []error{nil}, false},
+		{"produced after retry", []error{errBroker, errBroker, nil}, false},
+		{"every attempt failed", []error{errBroker, errBroker, errBroker}, true},
+	}
+
+	delay := dlqRetryDelay
+	dlqRetryDelay = time.Millisecond
+	t.Cleanup(func() { dlqRetryDelay = delay })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mocks.NewSyncProducer(t, nil)
+			for _, result := range tt.results {
+				if result != nil {
+					producer.ExpectSendMessageAndFail(result)
+					continue
+				}
+				producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+					if msg.Topic != topic.DLQ(topic.TopUpRequest) {
+						return fmt.Errorf("message is produced into %s", msg.Topic)
+					}
+					return nil
+				})
+			}
+
+			current := Producer
+			Producer = producer
+			t.Cleanup(func() { Producer = current })
+
+			message := &sarama.ConsumerMessage{Topic: topic.TopUpRequest, Value: []byte(`{}`)}
+			err := rejectMessage(context.Background(), message, errors.New("invalid message"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if err = producer.Close(); err != nil {
+				t.Errorf("unexpected produce attempts: %v", err)
+			}
+		})
+	}
+}
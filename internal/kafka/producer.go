@@ -1,12 +1,18 @@
 package kafka
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/Shopify/sarama"
 	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/kafka/topic"
+	"github.com/dw-account-service/internal/metrics"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/dw-account-service/internal/utilities/str"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 
 	//"github.com/dw-account-service/pkg/tools"
 	"strings"
@@ -17,46 +23,77 @@ var Producer sarama.SyncProducer
 func initProducer() error {
 	splitBrokers := strings.Split(configs.MainConfig.Kafka.Brokers, ",")
 
-	conf := configs.NewSaramaConfig()
+	conf, err := configs.NewSaramaConfig()
+	if err != nil {
+		return errors.New(fmt.Sprintf("| failed to create producer: %s", err.Error()))
+	}
 	conf.Producer.Retry.Max = configs.MainConfig.Kafka.Producer.RetryMax
 	conf.Producer.RequiredAcks = sarama.WaitForAll
 	conf.Producer.Return.Successes = true
 	//conf.Producer.Partitioner = sarama.NewRandomPartitioner
 	conf.Producer.Idempotent = configs.MainConfig.Kafka.Producer.Idempotent
 
-	syncProducer, err := sarama.NewSyncProducer(splitBrokers, conf)
+	client, err := sarama.NewClient(splitBrokers, conf)
 	if err != nil {
 		return errors.New(fmt.Sprintf("| failed to create producer: %s", err.Error()))
 	}
 
+	syncProducer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return errors.New(fmt.Sprintf("| failed to create producer: %s", err.Error()))
+	}
+
+	producerClient = client
 	Producer = syncProducer
-	utilities.Log.Println("| producer >> created")
+	utilities.Component("producer").Info().Msg("producer created")
 
 	return nil
 }
 
-func Initialize() error {
-	switch configs.MainConfig.Kafka.Mode {
-	case "producer":
-		if err := initProducer(); err != nil {
-			return err
-		}
-	case "consumer":
+// CloseProducer close the producer and its client, sync producer has no buffered message once SendMessage returned
+func CloseProducer() error {
+	if Producer == nil {
 		return nil
 	}
 
-	return nil
+	if err := Producer.Close(); err != nil {
+		return err
+	}
+
+	return producerClient.Close()
 }
 
-func ProduceMsg(topic string, payload []byte) error {
-	utilities.Log.SetPrefix("[PRODUCER] ")
-	_, _, err := Producer.SendMessage(&sarama.ProducerMessage{
-		Topic: topic,
+// Initialize create producer of the worker role, transaction results are published by the consumer with it
+func Initialize() error {
+	return initProducer()
+}
+
+// ProduceMsg send message to the topic, trace context of the context is propagated in the message headers
+func ProduceMsg(ctx context.Context, topicName string, payload []byte) error {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("%s publish", topicName),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(topicName),
+		),
+	)
+
+	msg := &sarama.ProducerMessage{
+		Topic: topicName,
 		Key:   sarama.StringEncoder(str.GetUnixTime()),
 		Value: sarama.StringEncoder(payload),
-	})
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(topic.HeaderVersion), Value: []byte(topic.LatestVersion(topicName))},
+		},
+	}
+	tracing.InjectMessage(ctx, msg)
+
+	_, _, err := Producer.SendMessage(msg)
+	tracing.End(span, err)
 	if err != nil {
-		utilities.Log.Println("| failed to send message to ", topic, err)
+		utilities.Logger(ctx).Error().Err(err).Str("destinationTopic", topicName).Msg("failed to send message")
+		metrics.ProducerFailed(topicName)
 		return err
 	}
 
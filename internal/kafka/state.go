@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/dw-account-service/internal/metrics"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrProducerNotReady = errors.New("producer is not connected to any broker")
+	ErrConsumerNotReady = errors.New("consumer group has no active session")
+)
+
+// PartitionLag is consumer lag of a claimed partition
+type PartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Lag       int64  `json:"lag"`
+}
+
+// claimState is claimed partition of current consumer group session, with offset of its next message
+type claimState struct {
+	claim sarama.ConsumerGroupClaim
+	next  int64
+}
+
+var consumerState = struct {
+	sync.RWMutex
+	started bool
+	active  bool
+	lastErr error
+	claims  map[string]*claimState
+}{claims: map[string]*claimState{}}
+
+// producerClient is used to check broker connection of the producer
+var producerClient sarama.Client
+
+func claimKey(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
+
+func setConsumerStarted() {
+	consumerState.Lock()
+	defer consumerState.Unlock()
+	consumerState.started = true
+}
+
+func setConsumerSession(active bool) {
+	consumerState.Lock()
+	defer consumerState.Unlock()
+	consumerState.active = active
+	if active {
+		consumerState.lastErr = nil
+	} else {
+		consumerState.claims = map[string]*claimState{}
+		metrics.ResetConsumerLag()
+	}
+}
+
+func setConsumerError(err error) {
+	consumerState.Lock()
+	defer consumerState.Unlock()
+	consumerState.lastErr = err
+}
+
+func trackClaim(claim sarama.ConsumerGroupClaim) {
+	consumerState.Lock()
+	defer consumerState.Unlock()
+	consumerState.claims[claimKey(claim.Topic(), claim.Partition())] = &claimState{
+		claim: claim,
+		next:  claim.InitialOffset(),
+	}
+}
+
+// markConsumed update next offset of the partition after the message has been handled
+func markConsumed(message *sarama.ConsumerMessage) {
+	consumerState.Lock()
+	defer consumerState.Unlock()
+	if state, ok := consumerState.claims[claimKey(message.Topic, message.Partition)]; ok {
+		state.next = message.Offset + 1
+		metrics.SetConsumerLag(message.Topic, message.Partition, state.claim.HighWaterMarkOffset()-state.next)
+	}
+}
+
+// ProducerStatus returns whether producer is initialized, and error when it cannot reach any broker
+func ProducerStatus() (bool, error) {
+	if Producer == nil || producerClient == nil {
+		return false, nil
+	}
+
+	if producerClient.Closed() {
+		return true, ErrProducerNotReady
+	}
+
+	for _, broker := range producerClient.Brokers() {
+		if connected, _ := broker.Connected(); connected {
+			return true, nil
+		}
+	}
+
+	return true, ErrProducerNotReady
+}
+
+// ConsumerStatus returns whether consumer is started, lag of its claimed partitions,
+// and error when consumer group has no active session
+func ConsumerStatus() (bool, []PartitionLag, error) {
+	consumerState.RLock()
+	defer consumerState.RUnlock()
+
+	if !consumerState.started {
+		return false, nil, nil
+	}
+
+	var lags []PartitionLag
+	for _, state := range consumerState.claims {
+		// offset is unknown before the first message is fetched
+		if state.next < 0 {
+			continue
+		}
+
+		lag := state.claim.HighWaterMarkOffset() - state.next
+		if lag < 0 {
+			lag = 0
+		}
+		lags = append(lags, PartitionLag{
+			Topic:     state.claim.Topic(),
+			Partition: state.claim.Partition(),
+			Lag:       lag,
+		})
+	}
+
+	sort.Slice(lags, func(i, j int) bool {
+		if lags[i].Topic == lags[j].Topic {
+			return lags[i].Partition < lags[j].Partition
+		}
+		return lags[i].Topic < lags[j].Topic
+	})
+
+	if !consumerState.active {
+		if consumerState.lastErr != nil {
+			return true, lags, fmt.Errorf("%w: %s", ErrConsumerNotReady, consumerState.lastErr.Error())
+		}
+		return true, lags, ErrConsumerNotReady
+	}
+
+	return true, lags, nil
+}
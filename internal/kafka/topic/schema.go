@@ -0,0 +1,167 @@
+package topic
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// HeaderVersion is message header of payload schema version, message without the header is version DefaultVersion
+	HeaderVersion  = "version"
+	DefaultVersion = "1"
+
+	// HeaderDLQReason and HeaderDLQTopic are added into message rejected to dead letter topic
+	HeaderDLQReason = "dlq-reason"
+	HeaderDLQTopic  = "dlq-original-topic"
+)
+
+// ErrUnsupportedVersion is returned when no schema registered for the message version
+var ErrUnsupportedVersion = errors.New("unsupported message version")
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// registry maps topic into its payload schema file of every version,
+// new version is added as new file, so older producers keep being validated with their version
+var registry = map[string]map[string]string{
+	TopUpRequest:              {"1": "schemas/topup.request.v1.json"},
+	TopUpResult:               {"1": "schemas/topup.result.v1.json"},
+	DeductRequest:             {"1": "schemas/deduct.request.v1.json"},
+	DeductResult:              {"1": "schemas/deduct.result.v1.json"},
+	DistributionRequest:       {"1": "schemas/distribute.request.v1.json"},
+	DistributionResult:        {"1": "schemas/distribute.result.v1.json"},
+	DistributionResultMembers: {"1": "schemas/distribute.result.members.v1.json"},
+}
+
+// schemas is compiled registry, key: topic, version
+var schemas = compileSchemas()
+
+func compileSchemas() map[string]map[string]*jsonschema.Schema {
+	compiled := map[string]map[string]*jsonschema.Schema{}
+
+	for topic, versions := range registry {
+		compiled[topic] = map[string]*jsonschema.Schema{}
+		for version, file := range versions {
+			content, err := schemaFiles.ReadFile(file)
+			if err != nil {
+				panic(fmt.Sprintf("cannot read schema %s: %s", file, err.Error()))
+			}
+
+			compiler := jsonschema.NewCompiler()
+			compiler.LoadURL = loadSchema
+			if err = compiler.AddResource(file, bytes.NewReader(content)); err != nil {
+				panic(fmt.Sprintf("invalid schema %s: %s", file, err.Error()))
+			}
+			compiled[topic][version] = compiler.MustCompile(file)
+		}
+	}
+
+	return compiled
+}
+
+// loadSchema load schema referenced with $ref by its file name, e.g. "transaction.result.v1.json",
+// from the embedded schemas. shared definition is kept in its own file and referenced by topic schemas
+func loadSchema(url string) (io.ReadCloser, error) {
+	file, err := schemaFiles.Open("schemas/" + path.Base(url))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load referenced schema %s: %s", url, err.Error())
+	}
+	return file, nil
+}
+
+// LatestVersion returns the latest schema version of the topic, it is set as version header of produced message
+func LatestVersion(topic string) string {
+	latest := DefaultVersion
+	for version := range registry[topic] {
+		v, _ := strconv.Atoi(version)
+		l, _ := strconv.Atoi(latest)
+		if v > l {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// MessageVersion returns value of version header of consumed message, DefaultVersion when it is not set
+func MessageVersion(headers []*sarama.RecordHeader) string {
+	for _, header := range headers {
+		if header != nil && string(header.Key) == HeaderVersion && len(header.Value) > 0 {
+			return string(header.Value)
+		}
+	}
+	return DefaultVersion
+}
+
+// Validate validate message payload against schema of the topic version,
+// empty version is treated as DefaultVersion. topic without registered schema is not validated
+func Validate(topic, version string, payload []byte) error {
+	versions, ok := schemas[topic]
+	if !ok {
+		return nil
+	}
+
+	if version == "" {
+		version = DefaultVersion
+	}
+
+	schema, ok := versions[version]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedVersion, version)
+	}
+
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return fmt.Errorf("invalid json payload: %s", err.Error())
+	}
+
+	if err := schema.Validate(v); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			return errors.New(validationMessage(validationErr))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// validationMessage returns message of every invalid field, e.g. /totalAmount: must be >= 0 but found -1
+func validationMessage(err *jsonschema.ValidationError) string {
+	var msg []string
+	for _, cause := range leafCauses(err) {
+		location := cause.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		msg = append(msg, fmt.Sprintf("%s: %s", location, cause.Message))
+	}
+	return strings.Join(msg, "; ")
+}
+
+func leafCauses(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var causes []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		causes = append(causes, leafCauses(cause)...)
+	}
+	return causes
+}
+
+// DLQ returns dead letter topic of the topic, invalid message is rejected into it
+func DLQ(topic string) string {
+	return topic + ".dlq"
+}
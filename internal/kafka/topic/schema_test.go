@@ -0,0 +1,39 @@
+package topic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		topic   string
+		version string
+		payload string
+		valid   bool
+	}{
+		{"valid topup", TopUpRequest, "", `{"transType":1,"partnerId":"MDL","merchantId":"M001","totalAmount":10000}`, true},
+		{"negative topup", TopUpRequest, "1", `{"transType":1,"partnerId":"MDL","merchantId":"M001","totalAmount":-1}`, false},
+		{"valid distribution", DistributionRequest, "1", `{"transType":3,"partnerId":"MDL","merchantId":"M001","items":[{"amount":5000}]}`, true},
+		{"distribution with zero item", DistributionRequest, "1", `{"transType":3,"partnerId":"MDL","merchantId":"M001","items":[{"amount":0}]}`, false},
+		{"topup result", TopUpResult, "1", `{"status":"00","totalAmount":10000,"items":null}`, true},
+		{"deduct result without status", DeductResult, "1", `{"totalAmount":10000}`, false},
+		{"distribution result", DistributionResult, "1", `{"status":"00","items":[{"amount":5000}]}`, true},
+		{"member result with invalid status", DistributionResultMembers, "1", `{"status":"success"}`, false},
+		{"invalid json", TopUpRequest, "1", `{`, false},
+		{"topic without schema", "other.topic", "1", `{`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.topic, tt.version, []byte(tt.payload)); (err == nil) != tt.valid {
+				t.Errorf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+
+	if err := Validate(TopUpRequest, "9", []byte(`{}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("got error %v, want %v", err, ErrUnsupportedVersion)
+	}
+}
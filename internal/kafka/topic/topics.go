@@ -1,5 +1,7 @@
 package topic
 
+import "github.com/Shopify/sarama"
+
 const (
 	TopUpRequest = "mdw.transaction.topup.request"
 	TopUpResult  = "mdw.transaction.topup.result"
@@ -11,3 +13,16 @@ const (
 	DistributionResult        = "mdw.transaction.distribute.result"
 	DistributionResultMembers = "mdw.transaction.distribute.result.members"
 )
+
+// HeaderCorrelationID is message header correlating logs of the message with its producer
+const HeaderCorrelationID = "correlation-id"
+
+// CorrelationID returns correlation id header of the message, message key is used when the header isn't set
+func CorrelationID(message *sarama.ConsumerMessage) string {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == HeaderCorrelationID && len(header.Value) > 0 {
+			return string(header.Value)
+		}
+	}
+	return string(message.Key)
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const namespace = "account_service"
+
+// message consumption outcome
+const (
+	OutcomeSuccess  = "success"
+	OutcomeFailed   = "failed"
+	OutcomeRejected = "rejected"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled http requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of handled http requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	consumedMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumed_messages_total",
+		Help:      "Number of consumed messages by topic and outcome (success, failed, rejected).",
+	}, []string{"topic", "outcome"})
+
+	processingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_message_processing_duration_seconds",
+		Help:      "Processing latency of consumed messages by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	consumerLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Number of messages behind the high water mark by claimed topic partition.",
+	}, []string{"topic", "partition"})
+
+	producerFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_producer_failures_total",
+		Help:      "Number of messages failed to be produced by topic.",
+	}, []string{"topic"})
+
+	distributionMembers = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "balance_distribution_members",
+		Help:      "Number of member accounts of balance distribution jobs.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	distributionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "balance_distribution_duration_seconds",
+		Help:      "Duration of balance distribution jobs.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongodb_command_duration_seconds",
+		Help:      "Latency of mongodb commands by command, collection and outcome (success, failed).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "collection", "outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		httpRequests,
+		httpDuration,
+		consumedMessages,
+		processingDuration,
+		consumerLag,
+		producerFailures,
+		distributionMembers,
+		distributionDuration,
+		mongoDuration,
+	)
+}
+
+// Handler serve metrics in prometheus exposition format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
+
+// HTTPMiddleware record count and latency of http requests, labeled by its route pattern to keep cardinality bounded
+func HTTPMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		labels := prometheus.Labels{
+			"method": c.Method(),
+			"route":  c.Route().Path,
+			"status": strconv.Itoa(status),
+		}
+		httpRequests.With(labels).Inc()
+		httpDuration.With(labels).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// ObserveMessage record outcome and processing latency of consumed message
+func ObserveMessage(topic, outcome string, duration time.Duration) {
+	consumedMessages.WithLabelValues(topic, outcome).Inc()
+	processingDuration.WithLabelValues(topic).Observe(duration.Seconds())
+}
+
+func SetConsumerLag(topic string, partition int32, lag int64) {
+	consumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+// ResetConsumerLag remove lag of every partition, when partitions are revoked from the consumer
+func ResetConsumerLag() {
+	consumerLag.Reset()
+}
+
+func ProducerFailed(topic string) {
+	producerFailures.WithLabelValues(topic).Inc()
+}
+
+// ObserveDistribution record number of members and duration of balance distribution job
+func ObserveDistribution(members int, duration time.Duration) {
+	distributionMembers.Observe(float64(members))
+	distributionDuration.Observe(duration.Seconds())
+}
+
+// CommandMonitor returns mongodb command monitor that record latency of every command
+func CommandMonitor() *event.CommandMonitor {
+	// collection is only known by started event, it is kept until the command finished
+	collections := sync.Map{}
+
+	collection := func(requestID int64) string {
+		if name, ok := collections.LoadAndDelete(requestID); ok {
+			return name.(string)
+		}
+		return ""
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			// the first element of command document is command name with collection as its value
+			name := ""
+			if elements, err := e.Command.Elements(); err == nil && len(elements) > 0 {
+				name, _ = elements[0].Value().StringValueOK()
+			}
+			collections.Store(e.RequestID, name)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, collection(e.RequestID), OutcomeSuccess).
+				Observe(time.Duration(e.DurationNanos).Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, collection(e.RequestID), OutcomeFailed).
+				Observe(time.Duration(e.DurationNanos).Seconds())
+		},
+	}
+}
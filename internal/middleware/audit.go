@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+const LocalsAffectedCount = "affectedCount"
+
+// SetAffectedCount set number of accounts affected by admin action, it's recorded by AdminAudit
+func SetAffectedCount(c *fiber.Ctx, count int64) {
+	c.Locals(LocalsAffectedCount, count)
+}
+
+// Caller returns identity of authenticated caller, bearer token subject or api key id
+func Caller(c *fiber.Ctx) string {
+	if subject := Subject(c); subject != "" {
+		return subject
+	}
+
+	if keyID, _ := c.Locals(LocalsKeyID).(string); keyID != "" {
+		return keyID
+	}
+
+	return "anonymous"
+}
+
+// requestParams collect query params and json body of the request
+func requestParams(c *fiber.Ctx) map[string]interface{} {
+	params := map[string]interface{}{}
+	if len(c.Body()) > 0 {
+		if err := json.Unmarshal(c.Body(), &params); err != nil {
+			params = map[string]interface{}{}
+		}
+	}
+
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		params[string(key)] = string(value)
+	})
+
+	return params
+}
+
+// AdminAudit record every call of admin action into admin audit log:
+// caller, action, parameters, affected account count (see SetAffectedCount) and the result
+func AdminAudit(action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		params := requestParams(c)
+
+		err := c.Next()
+
+		audit := &entity.AdminAuditLog{
+			Caller:     Caller(c),
+			Action:     action,
+			Method:     c.Method(),
+			Path:       c.Path(),
+			Params:     params,
+			StatusCode: c.Response().StatusCode(),
+			CreatedAt:  time.Now().UnixMilli(),
+		}
+		audit.CallerRoles, _ = c.Locals(LocalsRoles).([]string)
+		audit.AffectedCount, _ = c.Locals(LocalsAffectedCount).(int64)
+
+		if err != nil {
+			audit.Message = err.Error()
+			if e, ok := err.(*fiber.Error); ok {
+				audit.StatusCode = e.Code
+			} else {
+				audit.StatusCode = fiber.StatusInternalServerError
+			}
+		} else {
+			var response struct {
+				Message string `json:"message"`
+			}
+			_ = json.Unmarshal(c.Response().Body(), &response)
+			audit.Message = response.Message
+		}
+		audit.Success = audit.StatusCode < fiber.StatusBadRequest
+
+		repo := repository.NewAdminAuditRepository()
+		repo.Entity = audit
+		if _, errAudit := repo.Create(c.UserContext()); errAudit != nil {
+			utilities.Logger(c.UserContext()).Error().Err(errAudit).
+				Str("action", action).
+				Str("caller", audit.Caller).
+				Msg("failed to record admin audit log")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderAPIKey = "X-API-Key"
+
+	LocalsPartnerID = "partnerId"
+	LocalsKeyID     = "apiKeyId"
+
+	// how long partner credential is cached before re-fetched from database, every api process keeps its own cache
+	// so revoked api key is still accepted by a process until its cached credential is expired
+	credentialCacheTTL = time.Minute
+)
+
+type cachedCredential struct {
+	credential *entity.PartnerCredential
+	expiredAt  time.Time
+}
+
+var credentialCache = struct {
+	sync.RWMutex
+	items map[string]cachedCredential
+}{items: map[string]cachedCredential{}}
+
+func findCredential(ctx context.Context, keyID string) (*entity.PartnerCredential, error) {
+	credentialCache.RLock()
+	cached, ok := credentialCache.items[keyID]
+	credentialCache.RUnlock()
+
+	if ok && time.Now().Before(cached.expiredAt) {
+		return cached.credential, nil
+	}
+
+	repo := repository.NewCredentialRepository()
+	credential, err := repo.FindByKeyID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialCache.Lock()
+	credentialCache.items[keyID] = cachedCredential{credential: credential, expiredAt: time.Now().Add(credentialCacheTTL)}
+	credentialCache.Unlock()
+
+	return credential, nil
+}
+
+func sendUnauthorized(c *fiber.Ctx, msg string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(entity.Responses{
+		Success:   false,
+		Message:   msg,
+		ErrorCode: string(apperror.CodeUnauthorized),
+		Data:      nil,
+	})
+}
+
+func sendForbidden(c *fiber.Ctx, msg string) error {
+	return c.Status(fiber.StatusForbidden).JSON(entity.Responses{
+		Success:   false,
+		Message:   msg,
+		ErrorCode: string(apperror.CodeForbidden),
+		Data:      nil,
+	})
+}
+
+// APIKeyAuth authenticate request using partner api key (header X-API-Key: <keyId>.<secret>),
+// authenticated partner id is stored into request locals, see PartnerID
+func APIKeyAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKey := c.Get(HeaderAPIKey)
+		if apiKey == "" {
+			return sendUnauthorized(c, "missing api key")
+		}
+
+		keyID, secret, ok := strings.Cut(apiKey, ".")
+		if !ok || keyID == "" || secret == "" {
+			return sendUnauthorized(c, "invalid api key")
+		}
+
+		credential, err := findCredential(c.UserContext(), keyID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return sendUnauthorized(c, "invalid api key")
+			}
+
+			utilities.Logger(c.UserContext()).Error().Err(err).Str("keyId", keyID).Msg("failed to fetch partner credential")
+			return c.Status(fiber.StatusInternalServerError).JSON(entity.Responses{
+				Success:   false,
+				Message:   "failed to authenticate request",
+				ErrorCode: string(apperror.CodeInternal),
+				Data:      nil,
+			})
+		}
+
+		hash := crypt.HashAPIKeySecret(secret)
+		if !credential.Active || subtle.ConstantTimeCompare([]byte(hash), []byte(credential.KeyHash)) != 1 {
+			return sendUnauthorized(c, "invalid api key")
+		}
+
+		c.Locals(LocalsPartnerID, credential.PartnerID)
+		c.Locals(LocalsKeyID, credential.KeyID)
+		c.Locals(LocalsRoles, []string{RolePartner})
+
+		return c.Next()
+	}
+}
+
+// Authenticate authenticate request using bearer token (when auth.jwt is enabled)
+// or partner api key (when auth.apiKey is enabled). bearer token takes precedence when both are sent.
+func Authenticate() (fiber.Handler, error) {
+	conf := configs.MainConfig.Auth
+
+	var jwtAuth, apiKeyAuth fiber.Handler
+	if conf.JWT.Enable {
+		var err error
+		if jwtAuth, err = JWTAuth(); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.APIKey.Enable {
+		apiKeyAuth = APIKeyAuth()
+	}
+
+	return func(c *fiber.Ctx) error {
+		if jwtAuth != nil && (apiKeyAuth == nil || strings.HasPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")) {
+			return jwtAuth(c)
+		}
+
+		if apiKeyAuth != nil {
+			return apiKeyAuth(c)
+		}
+
+		return sendUnauthorized(c, "authentication is not configured")
+	}, nil
+}
+
+// RequireRoles allow request only if authenticated caller has one of the roles,
+// unauthenticated request is always rejected
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		callerRoles, _ := c.Locals(LocalsRoles).([]string)
+		for _, role := range roles {
+			if hasRole(callerRoles, role) {
+				return c.Next()
+			}
+		}
+
+		return sendForbidden(c, "insufficient role to access this resource")
+	}
+}
+
+// Subject returns authenticated bearer token subject of current request
+func Subject(c *fiber.Ctx) string {
+	subject, _ := c.Locals(LocalsSubject).(string)
+	return subject
+}
+
+// PartnerID returns authenticated partner id of current request, or empty string if request is not partner scoped
+func PartnerID(c *fiber.Ctx) string {
+	partnerID, _ := c.Locals(LocalsPartnerID).(string)
+	return partnerID
+}
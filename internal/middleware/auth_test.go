@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// cacheCredential store the credential into credential cache, so it's authenticated without database
+func cacheCredential(t *testing.T, credential *entity.PartnerCredential) {
+	t.Helper()
+
+	credentialCache.Lock()
+	credentialCache.items[credential.KeyID] = cachedCredential{credential: credential, expiredAt: time.Now().Add(time.Minute)}
+	credentialCache.Unlock()
+
+	t.Cleanup(func() {
+		credentialCache.Lock()
+		delete(credentialCache.items, credential.KeyID)
+		credentialCache.Unlock()
+	})
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	cacheCredential(t, &entity.PartnerCredential{
+		KeyID:     "active-key",
+		PartnerID: "MDL",
+		KeyHash:   crypt.HashAPIKeySecret("s3cret"),
+		Active:    true,
+	})
+	cacheCredential(t, &entity.PartnerCredential{
+		KeyID:     "revoked-key",
+		PartnerID: "MDL",
+		KeyHash:   crypt.HashAPIKeySecret("s3cret"),
+		Active:    false,
+	})
+
+	app := fiber.New()
+	app.Use(APIKeyAuth())
+	app.Get("/", func(c *fiber.Ctx) error {
+		roles, _ := c.Locals(LocalsRoles).([]string)
+		if len(roles) != 1 || roles[0] != RolePartner {
+			t.Errorf("unexpected roles of authenticated request: %v", roles)
+		}
+		return c.SendString(PartnerID(c) + "|" + Caller(c))
+	})
+
+	tests := []struct {
+		name   string
+		apiKey string
+		status int
+		body   string
+	}{
+		{"missing key", "", fiber.StatusUnauthorized, ""},
+		{"without secret", "active-key", fiber.StatusUnauthorized, ""},
+		{"empty key id", ".s3cret", fiber.StatusUnauthorized, ""},
+		{"wrong secret", "active-key.wrong", fiber.StatusUnauthorized, ""},
+		{"revoked key", "revoked-key.s3cret", fiber.StatusUnauthorized, ""},
+		{"valid key", "active-key.s3cret", fiber.StatusOK, "MDL|active-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			if tt.body != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Errorf("got body %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		roles  []string
+		status int
+	}{
+		{"unauthenticated", nil, fiber.StatusForbidden},
+		{"partner on admin route", []string{RolePartner}, fiber.StatusForbidden},
+		{"support on admin route", []string{RoleSupport}, fiber.StatusForbidden},
+		{"admin", []string{RoleSupport, RoleAdmin}, fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				if tt.roles != nil {
+					c.Locals(LocalsRoles, tt.roles)
+				}
+				return c.Next()
+			})
+			app.Get("/", RequireRoles(RoleAdmin), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
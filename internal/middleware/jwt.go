@@ -0,0 +1,329 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RolePartner = "partner"
+
+	LocalsSubject = "subject"
+	LocalsRoles   = "roles"
+
+	defaultRolesClaim   = "roles"
+	defaultPartnerClaim = "partner_id"
+
+	// cached jwks is re-fetched after this duration
+	jwksRefreshInterval = 15 * time.Minute
+	// minimum interval between jwks fetch triggered by unknown key id
+	jwksMinRefreshInterval = time.Minute
+)
+
+var jwtSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// keySet provide public keys to verify token signature, either from static PEM file or from (cached) JWKS endpoint
+type keySet struct {
+	mu        sync.RWMutex
+	jwksUrl   string
+	static    interface{}
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	client    *http.Client
+}
+
+func loadPublicKeyFile(path string) (interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("invalid PEM public key file")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.New("unsupported PEM public key format")
+	}
+
+	return cert.PublicKey, nil
+}
+
+func (k *keySet) fetch() error {
+	resp, err := k.client.Get(k.jwksUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected jwks response status %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			utilities.Component("auth").Warn().Err(err).Str("kid", jwk.Kid).Msg("skipping jwks key")
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	return nil
+}
+
+func (k *keySet) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// keyFunc returns verification key of the token, jwks is re-fetched when its expired or the key id is unknown
+func (k *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if k.static != nil {
+		return k.static, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	k.mu.RLock()
+	key, ok := k.lookup(kid)
+	expired := time.Since(k.fetchedAt) > jwksRefreshInterval
+	k.mu.RUnlock()
+
+	if ok && !expired {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if time.Since(k.fetchedAt) > jwksMinRefreshInterval {
+		if err := k.fetch(); err != nil {
+			utilities.Component("auth").Error().Err(err).Msg("failed to fetch jwks")
+		}
+	}
+
+	if key, ok = k.lookup(kid); !ok {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+
+	return key, nil
+}
+
+// claimValue returns claim value by dot separated path, e.g. realm_access.roles
+func claimValue(claims jwt.MapClaims, path string) interface{} {
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[name]
+	}
+	return value
+}
+
+// claimRoles accept roles claim as json array or space/comma separated string
+func claimRoles(claims jwt.MapClaims, path string) []string {
+	var roles []string
+	switch v := claimValue(claims, path).(type) {
+	case []interface{}:
+		for _, role := range v {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	case string:
+		roles = strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+	return roles
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTAuth authenticate request using bearer token (header Authorization: Bearer <token>)
+// issued by the identity provider and verified against configured jwks or public key.
+// token with partner role only is scoped to partner id claim, see PartnerID
+func JWTAuth() (fiber.Handler, error) {
+	conf := configs.MainConfig.Auth.JWT
+	keys := &keySet{jwksUrl: conf.JWKSUrl, client: &http.Client{Timeout: 5 * time.Second}}
+
+	switch {
+	case conf.PublicKeyFile != "":
+		key, err := loadPublicKeyFile(conf.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt public key, with err: %s", err.Error())
+		}
+		keys.static = key
+	case conf.JWKSUrl == "":
+		return nil, errors.New("auth.jwt requires jwksUrl or publicKeyFile")
+	}
+
+	rolesClaim := conf.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = defaultRolesClaim
+	}
+
+	partnerClaim := conf.PartnerClaim
+	if partnerClaim == "" {
+		partnerClaim = defaultPartnerClaim
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtSigningMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if conf.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(conf.Issuer))
+	}
+	if conf.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(conf.Audience))
+	}
+	parser := jwt.NewParser(parserOptions...)
+
+	return func(c *fiber.Ctx) error {
+		authorization := c.Get(fiber.HeaderAuthorization)
+		tokenString := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+		if !strings.HasPrefix(authorization, "Bearer ") || tokenString == "" {
+			return sendUnauthorized(c, "missing bearer token")
+		}
+
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(tokenString, claims, keys.keyFunc); err != nil {
+			utilities.Logger(c.UserContext()).Warn().Err(err).Msg("invalid bearer token")
+			return sendUnauthorized(c, "invalid bearer token")
+		}
+
+		roles := claimRoles(claims, rolesClaim)
+		subject, _ := claims.GetSubject()
+
+		// internal roles are not scoped to any partner
+		if !hasRole(roles, RoleAdmin) && !hasRole(roles, RoleSupport) && hasRole(roles, RolePartner) {
+			partnerID, _ := claimValue(claims, partnerClaim).(string)
+			if partnerID == "" {
+				return sendForbidden(c, "token doesn't contain partner id")
+			}
+			c.Locals(LocalsPartnerID, partnerID)
+		}
+
+		c.Locals(LocalsSubject, subject)
+		c.Locals(LocalsRoles, roles)
+
+		return c.Next()
+	}, nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	HeaderRequestID = "X-Request-Id"
+	LocalsRequestID = "requestId"
+)
+
+// RequestID returns id of the request, see RequestLogger
+func RequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals(LocalsRequestID).(string)
+	return requestID
+}
+
+// RequestLogger set logger with request id into user context of the request, so every log of the request operation
+// can be correlated. request id of the caller (X-Request-Id) is kept, otherwise it's generated.
+// on debug mode, every request is logged with its status and latency
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(HeaderRequestID)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Locals(LocalsRequestID, requestID)
+		c.Set(HeaderRequestID, requestID)
+
+		logger := utilities.ContextLogger(c.UserContext()).With().
+			Str("component", "rest-api").
+			Str("requestId", requestID).
+			Logger()
+		c.SetUserContext(utilities.WithLogger(c.UserContext(), logger))
+
+		err := c.Next()
+
+		if configs.MainConfig.DebugMode {
+			status := c.Response().StatusCode()
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			} else if err != nil {
+				status = fiber.StatusInternalServerError
+			}
+
+			event := utilities.Logger(c.UserContext()).Info().
+				Int("status", status).
+				Dur("latency", time.Since(start)).
+				Str("method", c.Method()).
+				Str("path", c.Path())
+			if configs.MainConfig.VerboseAPIResponse {
+				event = event.Bytes("response", c.Response().Body())
+			}
+			event.Msg("request handled")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+
+	// idle bucket is removed from memory store after this duration
+	bucketIdleTimeout = 10 * time.Minute
+)
+
+// RateLimitResult is the bucket state after a request token is taken
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// duration until the bucket is full again
+	Reset time.Duration
+	// duration until next token is available, only set when request is not allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keep token bucket state of every rate limit key
+type RateLimitStore interface {
+	// Take consume single token from the bucket of the key, bucket is refilled by rate tokens per second up to burst tokens
+	Take(key string, rate float64, burst int) RateLimitResult
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPurge time.Time
+	// now returns current time of the buckets
+	now func() time.Time
+}
+
+func NewMemoryRateLimitStore() RateLimitStore {
+	return newMemoryRateLimitStore(time.Now)
+}
+
+func newMemoryRateLimitStore(now func() time.Time) *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*tokenBucket{}, lastPurge: now(), now: now}
+}
+
+func (m *memoryRateLimitStore) Take(key string, rate float64, burst int) RateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	// purge idle bucket periodically, idle bucket is full anyway
+	if now.Sub(m.lastPurge) > bucketIdleTimeout {
+		for k, b := range m.buckets {
+			if now.Sub(b.updatedAt) > bucketIdleTimeout {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastPurge = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), updatedAt: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.updatedAt).Seconds()*rate)
+	b.updatedAt = now
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second))
+	return result
+}
+
+// routeGroup returns lower case first path segment after api prefix, e.g. /api/v1/Account/detail -> account.
+// routing is case-insensitive, so every casing of the path is the same group
+func routeGroup(path, prefix string) string {
+	group := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(path), strings.ToLower(prefix)), "/")
+	if idx := strings.Index(group, "/"); idx >= 0 {
+		group = group[:idx]
+	}
+	return group
+}
+
+// rateLimitKey returns identity of the caller, authenticated partner or subject, or client ip address
+func rateLimitKey(c *fiber.Ctx) string {
+	if partnerID := PartnerID(c); partnerID != "" {
+		return "partner:" + partnerID
+	}
+
+	if subject := Subject(c); subject != "" {
+		return "subject:" + subject
+	}
+
+	return "ip:" + c.IP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// RateLimit limit request rate of every caller per route group (first path segment after prefix) using token bucket,
+// rule is taken from rateLimit.groups.<group> or rateLimit.default. it must be registered after Authenticate.
+func RateLimit(prefix string, store RateLimitStore) fiber.Handler {
+	conf := configs.MainConfig.RateLimit
+
+	return func(c *fiber.Ctx) error {
+		group := routeGroup(c.Path(), prefix)
+
+		rule, ok := conf.Groups[group]
+		if !ok {
+			rule = conf.Default
+		}
+
+		// rate limit is disabled for the group
+		if rule.Rate <= 0 {
+			return c.Next()
+		}
+
+		burst := rule.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(rule.Rate))
+		}
+
+		result := store.Take(group+"|"+rateLimitKey(c), rule.Rate, burst)
+
+		c.Set(HeaderRateLimitLimit, strconv.Itoa(burst))
+		c.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		c.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, ceilSeconds(result.RetryAfter))
+			return c.Status(fiber.StatusTooManyRequests).JSON(entity.Responses{
+				Success:   false,
+				Message:   "too many requests, please retry after " + ceilSeconds(result.RetryAfter) + " seconds",
+				ErrorCode: string(apperror.CodeRateLimited),
+				Data:      nil,
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dw-account-service/configs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeClock is current time of memory rate limit store, advanced by the test
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	// rate 2 tokens per second, up to 3 tokens
+	const rate, burst = 2.0, 3
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    RateLimitResult
+	}{
+		{"new bucket is full", 0, RateLimitResult{Allowed: true, Remaining: 2, Reset: 500 * time.Millisecond}},
+		{"second token", 0, RateLimitResult{Allowed: true, Remaining: 1, Reset: time.Second}},
+		{"last token", 0, RateLimitResult{Allowed: true, Remaining: 0, Reset: 1500 * time.Millisecond}},
+		{"empty bucket", 0, RateLimitResult{Allowed: false, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{"partially refilled", 250 * time.Millisecond, RateLimitResult{Allowed: false, Remaining: 0, Reset: 1250 * time.Millisecond, RetryAfter: 250 * time.Millisecond}},
+		{"refilled single token", 250 * time.Millisecond, RateLimitResult{Allowed: true, Remaining: 0, Reset: 1500 * time.Millisecond}},
+		{"refill is capped at burst", time.Minute, RateLimitResult{Allowed: true, Remaining: 2, Reset: 500 * time.Millisecond}},
+	}
+
+	clock := &fakeClock{now: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)}
+	store := newMemoryRateLimitStore(clock.Now)
+
+	for _, tt := range tests {
+		clock.now = clock.now.Add(tt.advance)
+
+		if got := store.Take("account|partner:MDL", rate, burst); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// other key has its own bucket
+	if got := store.Take("account|partner:OTHER", rate, burst); !got.Allowed || got.Remaining != 2 {
+		t.Errorf("other key: got %+v, want full bucket", got)
+	}
+}
+
+func TestMemoryRateLimitStorePurge(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)}
+	store := newMemoryRateLimitStore(clock.Now)
+
+	store.Take("idle", 1, 1)
+	clock.now = clock.now.Add(bucketIdleTimeout + time.Second)
+	store.Take("active", 1, 1)
+
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("idle bucket is not purged")
+	}
+	if _, ok := store.buckets["active"]; !ok {
+		t.Error("active bucket is purged")
+	}
+}
+
+func TestRouteGroup(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/account/detail":  "account",
+		"/api/v1/Account/detail":  "account",
+		"/API/V1/ACCOUNT/detail":  "account",
+		"/api/v1/merchant":        "merchant",
+		"/api/v1/":                "",
+		"/api/v1/admin/account/x": "admin",
+	}
+
+	for path, want := range tests {
+		if got := routeGroup(path, "/api/v1"); got != want {
+			t.Errorf("routeGroup(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRateLimitPathCasing(t *testing.T) {
+	conf := configs.MainConfig.RateLimit
+	t.Cleanup(func() { configs.MainConfig.RateLimit = conf })
+
+	configs.MainConfig.RateLimit = configs.RateLimitConfig{
+		Enable: true,
+		Groups: map[string]configs.RateLimitRule{"account": {Rate: 0.001, Burst: 1}},
+	}
+
+	app := fiber.New()
+	app.Use("/api/v1", RateLimit("/api/v1", NewMemoryRateLimitStore()))
+	app.Get("/api/v1/account/detail", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/account/detail", fiber.StatusOK},
+		{"/api/v1/Account/detail", fiber.StatusTooManyRequests},
+		{"/API/V1/ACCOUNT/DETAIL", fiber.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/apperror"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Signature"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+
+	defaultSignatureTolerance = 5 * time.Minute
+)
+
+// nonce stores, see NewNonceStore
+const (
+	NonceStoreMongo  = "mongodb"
+	NonceStoreMemory = "memory"
+)
+
+// NonceStore keep used request nonce until its expired, to reject replayed request
+type NonceStore interface {
+	// Use store the nonce and returns false if the nonce has been used before
+	Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// NewNonceStore returns nonce store of auth.signature.nonceStore
+func NewNonceStore() NonceStore {
+	if configs.MainConfig.Auth.Signature.NonceStore == NonceStoreMemory {
+		return NewMemoryNonceStore()
+	}
+	return NewMongoNonceStore()
+}
+
+// mongoNonceStore share used nonce between api processes, so replayed request is rejected by every process
+type mongoNonceStore struct {
+	repo repository.NonceRepository
+}
+
+func NewMongoNonceStore() NonceStore {
+	return &mongoNonceStore{repo: repository.NewNonceRepository()}
+}
+
+func (m *mongoNonceStore) Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return m.repo.Use(ctx, nonce, ttl)
+}
+
+type memoryNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastPurge time.Time
+}
+
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{nonces: map[string]time.Time{}, lastPurge: time.Now()}
+}
+
+func (m *memoryNonceStore) Use(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	// purge expired nonce periodically
+	if now.Sub(m.lastPurge) > ttl {
+		for k, expiredAt := range m.nonces {
+			if now.After(expiredAt) {
+				delete(m.nonces, k)
+			}
+		}
+		m.lastPurge = now
+	}
+
+	if expiredAt, ok := m.nonces[nonce]; ok && now.Before(expiredAt) {
+		return false, nil
+	}
+
+	m.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// RequestStringToSign returns request message to be signed by partner:
+// METHOD \n PATH \n TIMESTAMP \n NONCE \n hex(sha256(BODY))
+func RequestStringToSign(method, path, timestamp, nonce string, body []byte) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s", method, path, timestamp, nonce, crypt.SHA256Hex(body))
+}
+
+// ResponseStringToSign returns response message signed by the service:
+// STATUS \n TIMESTAMP \n NONCE \n hex(sha256(BODY))
+func ResponseStringToSign(status int, timestamp, nonce string, body []byte) string {
+	return fmt.Sprintf("%d\n%s\n%s\n%s", status, timestamp, nonce, crypt.SHA256Hex(body))
+}
+
+func sendInvalidSignature(c *fiber.Ctx, msg string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(entity.Responses{
+		Success:   false,
+		Message:   msg,
+		ErrorCode: string(apperror.CodeUnauthorized),
+		Data:      nil,
+	})
+}
+
+// VerifySignature verify HMAC-SHA256 signature of partner request using partner signing secret,
+// rejects stale timestamp (auth.signature.toleranceSeconds) and replayed nonce, then sign the response.
+// it must be registered after APIKeyAuth, since the signing secret is taken from authenticated credential.
+// request authenticated by bearer token is not signed.
+func VerifySignature(store NonceStore) fiber.Handler {
+	tolerance := defaultSignatureTolerance
+	if configs.MainConfig.Auth.Signature.ToleranceSeconds > 0 {
+		tolerance = time.Duration(configs.MainConfig.Auth.Signature.ToleranceSeconds) * time.Second
+	}
+
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals(LocalsSubject).(string); ok {
+			return c.Next()
+		}
+
+		keyID, _ := c.Locals(LocalsKeyID).(string)
+		if keyID == "" {
+			return sendInvalidSignature(c, "request signature requires api key authentication")
+		}
+
+		credential, err := findCredential(c.UserContext(), keyID)
+		if err != nil || credential.SigningSecret == "" {
+			return sendInvalidSignature(c, "request signing is not configured for current credential")
+		}
+
+		timestamp := c.Get(HeaderTimestamp)
+		nonce := c.Get(HeaderNonce)
+		signature := c.Get(HeaderSignature)
+		if timestamp == "" || nonce == "" || signature == "" {
+			return sendInvalidSignature(c, "missing request signature headers")
+		}
+
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || math.Abs(time.Since(time.Unix(ts, 0)).Seconds()) > tolerance.Seconds() {
+			return sendInvalidSignature(c, "stale or invalid request timestamp")
+		}
+
+		expected := crypt.HMACSignature(
+			credential.SigningSecret,
+			RequestStringToSign(c.Method(), c.OriginalURL(), timestamp, nonce, c.Body()),
+		)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+			return sendInvalidSignature(c, "invalid request signature")
+		}
+
+		// nonce is kept as long as the timestamp is acceptable
+		unused, err := store.Use(c.UserContext(), keyID+":"+nonce, 2*tolerance)
+		if err != nil {
+			utilities.Logger(c.UserContext()).Error().Err(err).Str("keyId", keyID).Msg("failed to store request nonce")
+			return c.Status(fiber.StatusInternalServerError).JSON(entity.Responses{
+				Success:   false,
+				Message:   "failed to verify request signature",
+				ErrorCode: string(apperror.CodeInternal),
+				Data:      nil,
+			})
+		}
+
+		if !unused {
+			return sendInvalidSignature(c, "replayed request nonce")
+		}
+
+		if err = c.Next(); err != nil {
+			if err = c.App().Config().ErrorHandler(c, err); err != nil {
+				utilities.Logger(c.UserContext()).Error().Err(err).Msg("failed to handle request error")
+			}
+		}
+
+		// streamed response (e.g. file export) is not signed, since its body is not buffered
+		if c.Response().IsBodyStream() {
+			return nil
+		}
+
+		// sign response
+		responseTimestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		c.Set(HeaderTimestamp, responseTimestamp)
+		c.Set(HeaderNonce, nonce)
+		c.Set(HeaderSignature, crypt.HMACSignature(
+			credential.SigningSecret,
+			ResponseStringToSign(c.Response().StatusCode(), responseTimestamp, nonce, c.Response().Body()),
+		))
+
+		return nil
+	}
+}
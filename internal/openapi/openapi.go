@@ -0,0 +1,397 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Param is query parameter of an operation
+type Param struct {
+	Name        string
+	Description string
+	// Type: string | integer | boolean, default: string
+	Type string
+}
+
+// Operation describe request and response of a route
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Roles allowed to access the operation
+	Roles []string
+	Query []Param
+
+	// Request is request body type, e.g. entity.AccountBalance{}
+	Request interface{}
+	// RequestContentType of request body, default: application/json
+	RequestContentType string
+
+	// Response is type of data field in default response (entity.Responses),
+	// or type of results field when Paginated is true
+	Response  interface{}
+	Paginated bool
+	// RawResponse is used as is as response body type, instead of default response
+	RawResponse interface{}
+	// ResponseContentType of response body, e.g. text/csv for file download, default: application/json
+	ResponseContentTypes []string
+}
+
+// Spec collect documented operations and generate OpenAPI 3 document from it
+type Spec struct {
+	Title       string
+	Version     string
+	Description string
+
+	paths      map[string]map[string]interface{}
+	schemas    map[string]interface{}
+	operations map[string]Operation
+}
+
+func New(title, version, description string) *Spec {
+	return &Spec{
+		Title:       title,
+		Version:     version,
+		Description: description,
+		paths:       map[string]map[string]interface{}{},
+		schemas:     map[string]interface{}{},
+		operations:  map[string]Operation{},
+	}
+}
+
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// trimPath remove trailing slash, fiber doesn't use strict routing
+func trimPath(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// Add document operation of fiber route path, e.g. /api/v1/account/:id
+func (s *Spec) Add(method, path string, op Operation) {
+	var parameters []interface{}
+
+	s.operations[strings.ToUpper(method)+" "+trimPath(path)] = op
+	path = pathParamPattern.ReplaceAllStringFunc(trimPath(path), func(param string) string {
+		name := strings.TrimPrefix(param, ":")
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+		return "{" + name + "}"
+	})
+
+	for _, q := range op.Query {
+		paramType := q.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name":        q.Name,
+			"in":          "query",
+			"description": q.Description,
+			"schema":      map[string]interface{}{"type": paramType},
+		})
+	}
+
+	operation := map[string]interface{}{
+		"summary":   op.Summary,
+		"responses": s.responses(op),
+	}
+
+	if len(op.Tags) > 0 {
+		operation["tags"] = op.Tags
+	}
+
+	description := op.Description
+	if len(op.Roles) > 0 {
+		if description != "" {
+			description += "\n\n"
+		}
+		description += "Roles: " + strings.Join(op.Roles, ", ")
+		operation["x-roles"] = op.Roles
+	}
+	if description != "" {
+		operation["description"] = description
+	}
+
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if op.Request != nil {
+		contentType := op.RequestContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				contentType: map[string]interface{}{"schema": s.schema(reflect.TypeOf(op.Request))},
+			},
+		}
+	}
+
+	if _, ok := s.paths[path]; !ok {
+		s.paths[path] = map[string]interface{}{}
+	}
+	s.paths[path][strings.ToLower(method)] = operation
+}
+
+// Has returns true if method and fiber route path has been documented
+func (s *Spec) Has(method, path string) bool {
+	path = pathParamPattern.ReplaceAllString(trimPath(path), "{$1}")
+	_, ok := s.paths[path][strings.ToLower(method)]
+	return ok
+}
+
+// Operation returns documented operation of method and fiber route path
+func (s *Spec) Operation(method, path string) (Operation, bool) {
+	op, ok := s.operations[strings.ToUpper(method)+" "+trimPath(path)]
+	return op, ok
+}
+
+func (s *Spec) responses(op Operation) map[string]interface{} {
+	var schema interface{}
+	switch {
+	case op.RawResponse != nil:
+		schema = s.schema(reflect.TypeOf(op.RawResponse))
+	case op.Paginated:
+		schema = map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"success": map[string]interface{}{"type": "boolean"},
+				"message": map[string]interface{}{"type": "string"},
+				"data": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"total":      map[string]interface{}{"type": "integer", "format": "int64"},
+						"results":    map[string]interface{}{"type": "array", "items": s.schemaOf(op.Response)},
+						"pagination": s.schema(reflect.TypeOf(paginationInfo{})),
+					},
+				},
+			},
+		}
+	default:
+		schema = map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"success": map[string]interface{}{"type": "boolean"},
+				"message": map[string]interface{}{"type": "string"},
+				"total":   map[string]interface{}{"type": "integer"},
+				"data":    s.schemaOf(op.Response),
+			},
+		}
+	}
+
+	content := map[string]interface{}{}
+	if len(op.ResponseContentTypes) > 0 {
+		for _, contentType := range op.ResponseContentTypes {
+			content[contentType] = map[string]interface{}{
+				"schema": map[string]interface{}{"type": "string", "format": "binary"},
+			}
+		}
+	} else {
+		content["application/json"] = map[string]interface{}{"schema": schema}
+	}
+
+	errorResponse := map[string]interface{}{
+		"description": "request failed",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": s.schema(reflect.TypeOf(errorResponse{}))},
+		},
+	}
+
+	return map[string]interface{}{
+		"200":     map[string]interface{}{"description": "successful response", "content": content},
+		"default": errorResponse,
+	}
+}
+
+// paginationInfo and errorResponse mirror entity.PaginationInfo and entity.Responses,
+// so the package doesn't depend on entity package
+type paginationInfo struct {
+	PerPage     int64 `json:"perPage,omitempty"`
+	CurrentPage int64 `json:"currentPage,omitempty"`
+	LastPage    int64 `json:"lastPage,omitempty"`
+}
+
+type errorResponse struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	ErrorCode string      `json:"errorCode,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+func (s *Spec) schemaOf(v interface{}) interface{} {
+	if v == nil {
+		return map[string]interface{}{}
+	}
+	return s.schema(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns json schema of the type, named struct is registered as component and referenced
+func (s *Spec) schema(t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+
+		name := t.Name()
+		if _, ok := s.schemas[name]; !ok {
+			// placeholder to stop recursion of self referenced type
+			s.schemas[name] = map[string]interface{}{}
+			s.schemas[name] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	// interface{} accept any value
+	return map[string]interface{}{}
+}
+
+func (s *Spec) structSchema(t reflect.Type) interface{} {
+	properties := map[string]interface{}{}
+	s.collectProperties(t, properties)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+func (s *Spec) collectProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		// embedded struct fields are flattened
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.collectProperties(ft, properties)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schema(field.Type)
+	}
+}
+
+// JSON returns the OpenAPI 3 document
+func (s *Spec) JSON() ([]byte, error) {
+	// paths is sorted by encoding/json, tags are collected for stable document
+	tagSet := map[string]bool{}
+	for _, operations := range s.paths {
+		for _, operation := range operations {
+			if tags, ok := operation.(map[string]interface{})["tags"].([]string); ok {
+				for _, tag := range tags {
+					tagSet[tag] = true
+				}
+			}
+		}
+	}
+
+	var tags []interface{}
+	var tagNames []string
+	for tag := range tagSet {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	for _, tag := range tagNames {
+		tags = append(tags, map[string]interface{}{"name": tag})
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       s.Title,
+			"version":     s.Version,
+			"description": s.Description,
+		},
+		"tags":  tags,
+		"paths": s.paths,
+		"components": map[string]interface{}{
+			"schemas": s.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKeyAuth": []string{}},
+		},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// DocsHTML returns swagger ui page of the document url
+func DocsHTML(specURL string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8"/>
+  <title>API Documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css"/>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+  window.onload = function () {
+    window.ui = SwaggerUIBundle({url: "` + specURL + `", dom_id: "#swagger-ui"});
+  };
+</script>
+</body>
+</html>`
+}
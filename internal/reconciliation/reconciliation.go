@@ -0,0 +1,293 @@
+package reconciliation
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// supported partnerTransDate format on settlement file
+var transDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"20060102150405",
+	"2006-01-02",
+	"20060102",
+}
+
+// settlement file column name -->> aliases (case-insensitive)
+var columnAliases = map[string][]string{
+	"partnerRefNumber": {"partnerrefnumber", "partner_ref_number", "refnumber", "ref_number"},
+	"partnerTransDate": {"partnertransdate", "partner_trans_date", "transdate", "trans_date"},
+	"merchantId":       {"merchantid", "merchant_id"},
+	"terminalId":       {"terminalid", "terminal_id"},
+	"amount":           {"amount", "totalamount", "total_amount"},
+}
+
+func parseTransDate(value string) (time.Time, error) {
+	for _, layout := range transDateLayouts {
+		t, err := time.ParseInLocation(layout, strings.TrimSpace(value), time.Now().Location())
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid partnerTransDate value: %s", value)
+}
+
+// ParseSettlementFile read partner settlement CSV file, the first row must be the column header.
+// required columns: partnerRefNumber, partnerTransDate, amount
+func ParseSettlementFile(r io.Reader) ([]entity.SettlementRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty settlement file")
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for idx, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range columnAliases {
+			for _, alias := range aliases {
+				if name == alias {
+					columns[column] = idx
+				}
+			}
+		}
+	}
+
+	for _, required := range []string{"partnerRefNumber", "partnerTransDate", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("settlement file must contains %s column", required)
+		}
+	}
+
+	value := func(row []string, column string) string {
+		idx, ok := columns[column]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	var records []entity.SettlementRecord
+	line := 1
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err.Error())
+		}
+
+		record := entity.SettlementRecord{
+			Line:             line,
+			PartnerRefNumber: value(row, "partnerRefNumber"),
+			PartnerTransDate: value(row, "partnerTransDate"),
+			MerchantID:       value(row, "merchantId"),
+			TerminalID:       value(row, "terminalId"),
+		}
+
+		if record.PartnerRefNumber == "" {
+			return nil, fmt.Errorf("line %d: partnerRefNumber cannot be empty", line)
+		}
+
+		if _, err = parseTransDate(record.PartnerTransDate); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err.Error())
+		}
+
+		record.Amount, err = strconv.ParseInt(value(row, "amount"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid amount value", line)
+		}
+
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("settlement file doesn't contain any record")
+	}
+
+	return records, nil
+}
+
+// Reconcile match partner settlement records against processed top-up transactions.
+// if start or end is zero, the period is taken from the earliest and latest partnerTransDate on records.
+func Reconcile(ctx context.Context, partnerID string, records []entity.SettlementRecord, start, end time.Time) (*entity.ReconciliationReport, error) {
+	if partnerID == "" {
+		return nil, errors.New("partnerId cannot be empty")
+	}
+
+	refNumbers := make([]string, 0, len(records))
+	var minDate, maxDate time.Time
+	for i, record := range records {
+		refNumbers = append(refNumbers, record.PartnerRefNumber)
+
+		t, err := parseTransDate(record.PartnerTransDate)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 || t.Before(minDate) {
+			minDate = t
+		}
+		if i == 0 || t.After(maxDate) {
+			maxDate = t
+		}
+	}
+
+	if start.IsZero() {
+		start = time.Date(minDate.Year(), minDate.Month(), minDate.Day(), 0, 0, 0, 0, minDate.Location())
+	}
+	if end.IsZero() {
+		end = time.Date(maxDate.Year(), maxDate.Month(), maxDate.Day(), 23, 59, 59, 0, maxDate.Location())
+	}
+
+	statementRepo := repository.NewStatementRepository()
+	transactions, err := statementRepo.FindPartnerTopUps(ctx, partnerID, refNumbers, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return match(partnerID, records, transactions, start, end), nil
+}
+
+// partnerItem returns report item of the settlement record
+func partnerItem(record entity.SettlementRecord) entity.ReconciliationItem {
+	return entity.ReconciliationItem{
+		PartnerRefNumber: record.PartnerRefNumber,
+		PartnerTransDate: record.PartnerTransDate,
+		MerchantID:       record.MerchantID,
+		TerminalID:       record.TerminalID,
+		PartnerAmount:    record.Amount,
+		Line:             record.Line,
+	}
+}
+
+// transactionItem returns report item of the processed top-up transaction
+func transactionItem(trx entity.BalanceMovement) entity.ReconciliationItem {
+	return entity.ReconciliationItem{
+		PartnerRefNumber: trx.PartnerRefNumber,
+		PartnerTransDate: trx.PartnerTransDate,
+		MerchantID:       trx.MerchantID,
+		TerminalID:       trx.TerminalID,
+		Amount:           trx.Credit,
+		ReceiptNumber:    trx.ReceiptNumber,
+		TransDate:        trx.TransDate,
+	}
+}
+
+// match pair settlement records and transactions of the same partnerRefNumber in order of file line and transaction date.
+// unpaired record or transaction of partnerRefNumber which exists on the other side is reported as duplicate,
+// otherwise it's reported as missing on the other side
+func match(partnerID string, records []entity.SettlementRecord, transactions []entity.BalanceMovement, start, end time.Time) *entity.ReconciliationReport {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].TransDateNumeric < transactions[j].TransDateNumeric
+	})
+
+	ours := make(map[string][]entity.BalanceMovement, len(transactions))
+	for _, trx := range transactions {
+		ours[trx.PartnerRefNumber] = append(ours[trx.PartnerRefNumber], trx)
+	}
+
+	partners := make(map[string][]entity.SettlementRecord, len(records))
+	for _, record := range records {
+		partners[record.PartnerRefNumber] = append(partners[record.PartnerRefNumber], record)
+	}
+
+	report := &entity.ReconciliationReport{
+		PartnerID:              partnerID,
+		PeriodStart:            start.Format("20060102"),
+		PeriodEnd:              end.Format("20060102"),
+		Matched:                make([]entity.ReconciliationItem, 0),
+		MissingOnOurSide:       make([]entity.ReconciliationItem, 0),
+		MissingOnPartnerSide:   make([]entity.ReconciliationItem, 0),
+		AmountMismatched:       make([]entity.ReconciliationItem, 0),
+		DuplicateOnOurSide:     make([]entity.ReconciliationItem, 0),
+		DuplicateOnPartnerSide: make([]entity.ReconciliationItem, 0),
+	}
+
+	// index of the record among records with the same partnerRefNumber
+	paired := make(map[string]int, len(records))
+	for _, record := range records {
+		report.Summary.TotalPartnerRecords++
+		report.Summary.TotalPartnerAmount += record.Amount
+
+		item := partnerItem(record)
+		trxs := ours[record.PartnerRefNumber]
+		idx := paired[record.PartnerRefNumber]
+		paired[record.PartnerRefNumber]++
+
+		switch {
+		case len(trxs) == 0:
+			report.MissingOnOurSide = append(report.MissingOnOurSide, item)
+			continue
+		case idx >= len(trxs):
+			report.DuplicateOnPartnerSide = append(report.DuplicateOnPartnerSide, item)
+			continue
+		}
+
+		trx := trxs[idx]
+		item.MerchantID = trx.MerchantID
+		item.TerminalID = trx.TerminalID
+		item.Amount = trx.Credit
+		item.ReceiptNumber = trx.ReceiptNumber
+		item.TransDate = trx.TransDate
+
+		if trx.Credit != record.Amount {
+			report.AmountMismatched = append(report.AmountMismatched, item)
+			continue
+		}
+
+		report.Matched = append(report.Matched, item)
+	}
+
+	for ref, trxs := range ours {
+		count := len(partners[ref])
+		for idx := count; idx < len(trxs); idx++ {
+			if count == 0 {
+				report.MissingOnPartnerSide = append(report.MissingOnPartnerSide, transactionItem(trxs[idx]))
+			} else {
+				report.DuplicateOnOurSide = append(report.DuplicateOnOurSide, transactionItem(trxs[idx]))
+			}
+		}
+	}
+
+	for _, trx := range transactions {
+		report.Summary.TotalTransactions++
+		report.Summary.TotalAmount += trx.Credit
+	}
+
+	byTransDate := func(items []entity.ReconciliationItem) {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].TransDate != items[j].TransDate {
+				return items[i].TransDate < items[j].TransDate
+			}
+			return items[i].ReceiptNumber < items[j].ReceiptNumber
+		})
+	}
+	byTransDate(report.MissingOnPartnerSide)
+	byTransDate(report.DuplicateOnOurSide)
+
+	report.Summary.Matched = len(report.Matched)
+	report.Summary.MissingOnOurSide = len(report.MissingOnOurSide)
+	report.Summary.MissingOnPartnerSide = len(report.MissingOnPartnerSide)
+	report.Summary.AmountMismatched = len(report.AmountMismatched)
+	report.Summary.DuplicateOnOurSide = len(report.DuplicateOnOurSide)
+	report.Summary.DuplicateOnPartnerSide = len(report.DuplicateOnPartnerSide)
+
+	return report
+}
@@ -0,0 +1,121 @@
+package reconciliation
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dw-account-service/internal/db/entity"
+)
+
+func TestParseSettlementFile(t *testing.T) {
+	file := "\ufeffRef_Number, Trans_Date, Merchant_ID, Total_Amount\n" +
+		"REF-1, 2023-10-01 10:00:00, M001, 10000\n" +
+		"REF-2, 20231002, , 20000\n"
+
+	got, err := ParseSettlementFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []entity.SettlementRecord{
+		{Line: 2, PartnerRefNumber: "REF-1", PartnerTransDate: "2023-10-01 10:00:00", MerchantID: "M001", Amount: 10000},
+		{Line: 3, PartnerRefNumber: "REF-2", PartnerTransDate: "20231002", Amount: 20000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseSettlementFileError(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"empty file", "", "empty settlement file"},
+		{"missing column", "partnerRefNumber,amount\nREF-1,100\n", "settlement file must contains partnerTransDate column"},
+		{"header only", "partnerRefNumber,partnerTransDate,amount\n", "settlement file doesn't contain any record"},
+		{"empty ref", "partnerRefNumber,partnerTransDate,amount\n,20231001,100\n", "line 2: partnerRefNumber cannot be empty"},
+		{"invalid date", "partnerRefNumber,partnerTransDate,amount\nREF-1,01/10/2023,100\n", "line 2: invalid partnerTransDate value: 01/10/2023"},
+		{"invalid amount", "partnerRefNumber,partnerTransDate,amount\nREF-1,20231001,10.5\n", "line 2: invalid amount value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSettlementFile(strings.NewReader(tt.file))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func refs(items []entity.ReconciliationItem) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		id := item.ReceiptNumber
+		if item.Line > 0 {
+			id = "line" + strconv.Itoa(item.Line)
+		}
+		result = append(result, item.PartnerRefNumber+":"+id)
+	}
+	return result
+}
+
+func TestMatch(t *testing.T) {
+	records := []entity.SettlementRecord{
+		{Line: 2, PartnerRefNumber: "MATCHED", Amount: 100},
+		{Line: 3, PartnerRefNumber: "MISMATCHED", Amount: 200},
+		{Line: 4, PartnerRefNumber: "NOT-PROCESSED", Amount: 300},
+		{Line: 5, PartnerRefNumber: "TWICE-IN-FILE", Amount: 400},
+		{Line: 6, PartnerRefNumber: "TWICE-IN-FILE", Amount: 400},
+		{Line: 7, PartnerRefNumber: "CREDITED-TWICE", Amount: 500},
+	}
+	transactions := []entity.BalanceMovement{
+		{PartnerRefNumber: "CREDITED-TWICE", Credit: 500, ReceiptNumber: "R6", TransDateNumeric: 6},
+		{PartnerRefNumber: "MATCHED", Credit: 100, ReceiptNumber: "R1", TransDateNumeric: 1},
+		{PartnerRefNumber: "MISMATCHED", Credit: 250, ReceiptNumber: "R2", TransDateNumeric: 2},
+		{PartnerRefNumber: "TWICE-IN-FILE", Credit: 400, ReceiptNumber: "R3", TransDateNumeric: 3},
+		{PartnerRefNumber: "NOT-IN-FILE", Credit: 600, ReceiptNumber: "R4", TransDateNumeric: 4},
+		{PartnerRefNumber: "CREDITED-TWICE", Credit: 500, ReceiptNumber: "R5", TransDateNumeric: 5},
+	}
+
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	report := match("MDL", records, transactions, start, start.AddDate(0, 0, 1))
+
+	tests := []struct {
+		name  string
+		got   []entity.ReconciliationItem
+		count int
+		want  []string
+	}{
+		// the earliest transaction is paired with the record
+		{"matched", report.Matched, report.Summary.Matched, []string{"MATCHED:line2", "TWICE-IN-FILE:line5", "CREDITED-TWICE:line7"}},
+		{"amount mismatched", report.AmountMismatched, report.Summary.AmountMismatched, []string{"MISMATCHED:line3"}},
+		{"missing on our side", report.MissingOnOurSide, report.Summary.MissingOnOurSide, []string{"NOT-PROCESSED:line4"}},
+		{"missing on partner side", report.MissingOnPartnerSide, report.Summary.MissingOnPartnerSide, []string{"NOT-IN-FILE:R4"}},
+		{"duplicate on partner side", report.DuplicateOnPartnerSide, report.Summary.DuplicateOnPartnerSide, []string{"TWICE-IN-FILE:line6"}},
+		{"duplicate on our side", report.DuplicateOnOurSide, report.Summary.DuplicateOnOurSide, []string{"CREDITED-TWICE:R6"}},
+	}
+
+	for _, tt := range tests {
+		if got := refs(tt.got); !reflect.DeepEqual(got, tt.want) || tt.count != len(tt.want) {
+			t.Errorf("%s: got %q (count %d), want %q", tt.name, got, tt.count, tt.want)
+		}
+	}
+
+	if report.Matched[2].ReceiptNumber != "R5" {
+		t.Errorf("got record paired with %s, want the earliest transaction R5", report.Matched[2].ReceiptNumber)
+	}
+
+	summary := report.Summary
+	if summary.TotalPartnerRecords != 6 || summary.TotalPartnerAmount != 1900 || summary.TotalTransactions != 6 || summary.TotalAmount != 2350 {
+		t.Errorf("got totals %+v", summary)
+	}
+	if report.PeriodStart != "20231001" || report.PeriodEnd != "20231002" {
+		t.Errorf("got period %s - %s", report.PeriodStart, report.PeriodEnd)
+	}
+}
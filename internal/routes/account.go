@@ -1,32 +1,111 @@
 package routes
 
 import (
+	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/handlers"
+	"github.com/dw-account-service/internal/openapi"
 	"github.com/gofiber/fiber/v2"
 )
 
-func initAccountRoutes(router fiber.Router) {
+var exportQuery = []openapi.Param{{Name: "format", Description: "csv (default) | xlsx"}}
+
+var exportContentTypes = []string{"text/csv", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+
+func initAccountRoutes(router fiber.Router, spec *openapi.Spec) {
 	accountHandler := handlers.NewAccountHandler()
 
 	accountRoutes := router.Group("/account")
 
-	accountRoutes.Post("/register", func(c *fiber.Ctx) error {
+	handle(spec, accountRoutes, fiber.MethodPost, "/register", openapi.Operation{
+		Summary:  "Register regular or merchant account",
+		Tags:     []string{"account"},
+		Roles:    writeRoles,
+		Request:  entity.AccountBalance{},
+		Response: entity.AccountBalance{},
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.Register(c)
 	})
 
-	accountRoutes.Post("/unregister", func(c *fiber.Ctx) error {
+	handle(spec, accountRoutes, fiber.MethodPost, "/register/bulk", openapi.Operation{
+		Summary:     "Register members of a merchant",
+		Description: "Accept json payload or multipart csv file (file, partnerId, merchantId, async). Async request returns the registration job.",
+		Tags:        []string{"account"},
+		Roles:       writeRoles,
+		Request:     entity.BulkRegistrationRequest{},
+		Response:    []entity.BulkRegistrationResult{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.BulkRegister(c)
+	})
+
+	handle(spec, accountRoutes, fiber.MethodGet, "/register/bulk/:id", openapi.Operation{
+		Summary:     "Get bulk registration job",
+		Description: "Row results are paginated by row number, default size is the maximum page size.",
+		Tags:        []string{"account"},
+		Roles:       readRoles,
+		Query:       pageQuery,
+		Response:    entity.BulkRegistrationJob{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.GetBulkRegistrationJob(c)
+	})
+
+	handle(spec, router, fiber.MethodPatch, "/account", openapi.Operation{
+		Summary:  "Update account profile",
+		Tags:     []string{"account"},
+		Roles:    writeRoles,
+		Request:  entity.UpdateAccountRequest{},
+		Response: entity.AccountBalance{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.UpdateAccount(c)
+	})
+
+	handle(spec, accountRoutes, fiber.MethodPost, "/unregister", openapi.Operation{
+		Summary:  "Deactivate account",
+		Tags:     []string{"account"},
+		Roles:    writeRoles,
+		Request:  entity.UnregisterAccount{},
+		Response: entity.AccountBalance{},
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.Unregister(c)
 	})
 
-	accountRoutes.Post("/all", func(c *fiber.Ctx) error {
+	handle(spec, accountRoutes, fiber.MethodPost, "/all", openapi.Operation{
+		Summary:   "List accounts",
+		Tags:      []string{"account"},
+		Roles:     readRoles,
+		Request:   entity.PaginatedAccountRequest{},
+		Response:  entity.AccountBalance{},
+		Paginated: true,
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.GetAccountsPaginated(c)
 	})
 
-	accountRoutes.Get("/:id", func(c *fiber.Ctx) error {
+	handle(spec, accountRoutes, fiber.MethodPost, "/export", openapi.Operation{
+		Summary:              "Export accounts",
+		Tags:                 []string{"account"},
+		Roles:                readRoles,
+		Query:                exportQuery,
+		Request:              entity.PaginatedAccountRequest{},
+		ResponseContentTypes: exportContentTypes,
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.ExportAccounts(c)
+	})
+
+	handle(spec, accountRoutes, fiber.MethodGet, "/:id", openapi.Operation{
+		Summary:  "Get account by id",
+		Tags:     []string{"account"},
+		Roles:    readRoles,
+		Response: entity.AccountBalance{},
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.GetAccountByID(c)
 	})
 
-	accountRoutes.Post("/detail", func(c *fiber.Ctx) error {
+	handle(spec, accountRoutes, fiber.MethodPost, "/detail", openapi.Operation{
+		Summary:  "Get account by partnerId, merchantId and terminalId",
+		Tags:     []string{"account"},
+		Roles:    readRoles,
+		Request:  entity.AccountBalance{},
+		Response: entity.AccountBalance{},
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.GetAccount(c)
 	})
 
@@ -34,22 +113,35 @@ func initAccountRoutes(router fiber.Router) {
 
 	merchantRoutes := router.Group("/merchant")
 
-	merchantRoutes.Post("/members", func(c *fiber.Ctx) error {
+	handle(spec, merchantRoutes, fiber.MethodPost, "/members", openapi.Operation{
+		Summary:     "List merchant members",
+		Tags:        []string{"merchant"},
+		Roles:       readRoles,
+		Request:     entity.PaginatedAccountRequest{},
+		RawResponse: entity.PaginatedResponseMembers{},
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.GetMerchantMembers(c, false)
 	})
 
-	merchantRoutes.Post("/members/period", func(c *fiber.Ctx) error {
+	handle(spec, merchantRoutes, fiber.MethodPost, "/members/period", openapi.Operation{
+		Summary:     "List merchant members registered within periods",
+		Tags:        []string{"merchant"},
+		Roles:       readRoles,
+		Request:     entity.PaginatedAccountRequest{},
+		RawResponse: entity.PaginatedResponseMembers{},
+	}, func(c *fiber.Ctx) error {
 		return accountHandler.GetMerchantMembers(c, true)
 	})
 
-	// -------------------------- TOOLS --------------------------
-
-	accountRoutes.Post("/update-merchant-and-terminal", func(c *fiber.Ctx) error {
-		return accountHandler.UpdateMerchantAndTerminalForAccount(c)
-	})
-
-	accountRoutes.Post("/sync-balance", func(c *fiber.Ctx) error {
-		return accountHandler.SyncBalance(c)
+	handle(spec, merchantRoutes, fiber.MethodPost, "/members/export", openapi.Operation{
+		Summary:              "Export merchant members",
+		Tags:                 []string{"merchant"},
+		Roles:                readRoles,
+		Query:                exportQuery,
+		Request:              entity.PaginatedAccountRequest{},
+		ResponseContentTypes: exportContentTypes,
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.ExportMerchantMembers(c)
 	})
 
 }
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/handlers"
+	"github.com/dw-account-service/internal/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+var pageQuery = []openapi.Param{
+	{Name: "page", Type: "integer"},
+	{Name: "size", Type: "integer"},
+}
+
+// initAdminRoutes register internal tools, every action is recorded into admin audit log
+func initAdminRoutes(router fiber.Router, spec *openapi.Spec) {
+	accountHandler := handlers.NewAccountHandler()
+	adminHandler := handlers.NewAdminHandler()
+
+	r := router.Group("/admin")
+
+	handleAudited(spec, r, fiber.MethodPost, "/account/update-merchant-and-terminal", "update-merchant-and-terminal", openapi.Operation{
+		Summary:     "Fill empty merchantId and terminalId of accounts",
+		Tags:        []string{"admin"},
+		Roles:       adminRoles,
+		RawResponse: fiber.Map{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.UpdateMerchantAndTerminalForAccount(c)
+	})
+
+	handleAudited(spec, r, fiber.MethodPost, "/account/sync-balance", "sync-balance", openapi.Operation{
+		Summary:     "Sync encrypted balance of accounts",
+		Tags:        []string{"admin"},
+		Roles:       adminRoles,
+		RawResponse: fiber.Map{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.SyncBalance(c)
+	})
+
+	handleAudited(spec, r, fiber.MethodPost, "/account/balance-snapshot", "balance-snapshot", openapi.Operation{
+		Summary:     "Take daily balance snapshot",
+		Tags:        []string{"admin"},
+		Roles:       adminRoles,
+		Request:     entity.SnapshotRequest{},
+		RawResponse: fiber.Map{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.TakeBalanceSnapshot(c)
+	})
+
+	handleAudited(spec, r, fiber.MethodPost, "/account/reactivate", "reactivate-account", openapi.Operation{
+		Summary:  "Reactivate deactivated account",
+		Tags:     []string{"admin"},
+		Roles:    adminRoles,
+		Request:  entity.ReactivateAccountRequest{},
+		Response: entity.AccountBalance{},
+	}, func(c *fiber.Ctx) error {
+		return accountHandler.ReactivateAccount(c)
+	})
+
+	handleAudited(spec, r, fiber.MethodPost, "/adjustment", "submit-adjustment", openapi.Operation{
+		Summary:  "Submit balance adjustment",
+		Tags:     []string{"admin"},
+		Roles:    adminRoles,
+		Request:  entity.BalanceAdjustmentRequest{},
+		Response: entity.BalanceAdjustment{},
+	}, func(c *fiber.Ctx) error {
+		return adminHandler.SubmitAdjustment(c)
+	})
+
+	handleAudited(spec, r, fiber.MethodPost, "/adjustment/:id/approve", "approve-adjustment", openapi.Operation{
+		Summary:  "Approve and apply balance adjustment",
+		Tags:     []string{"admin"},
+		Roles:    adminRoles,
+		Response: entity.BalanceAdjustment{},
+	}, func(c *fiber.Ctx) error {
+		return adminHandler.ApproveAdjustment(c)
+	})
+
+	handleAudited(spec, r, fiber.MethodPost, "/adjustment/:id/reject", "reject-adjustment", openapi.Operation{
+		Summary:  "Reject balance adjustment",
+		Tags:     []string{"admin"},
+		Roles:    adminRoles,
+		Request:  entity.BalanceAdjustmentReview{},
+		Response: entity.BalanceAdjustment{},
+	}, func(c *fiber.Ctx) error {
+		return adminHandler.RejectAdjustment(c)
+	})
+
+	handle(spec, r, fiber.MethodGet, "/adjustment", openapi.Operation{
+		Summary:   "List balance adjustments",
+		Tags:      []string{"admin"},
+		Roles:     adminRoles,
+		Query:     append([]openapi.Param{{Name: "status", Description: "pending | approved | rejected | failed"}}, pageQuery...),
+		Response:  entity.BalanceAdjustment{},
+		Paginated: true,
+	}, func(c *fiber.Ctx) error {
+		return adminHandler.GetAdjustments(c)
+	})
+
+	handle(spec, r, fiber.MethodGet, "/audit", openapi.Operation{
+		Summary: "List admin audit logs",
+		Tags:    []string{"admin"},
+		Roles:   adminRoles,
+		Query: append([]openapi.Param{
+			{Name: "action"},
+			{Name: "caller"},
+			{Name: "start", Description: "yyyyMMdd"},
+			{Name: "end", Description: "yyyyMMdd"},
+		}, pageQuery...),
+		Response:  entity.AdminAuditLog{},
+		Paginated: true,
+	}, func(c *fiber.Ctx) error {
+		return adminHandler.GetAuditLogs(c)
+	})
+}
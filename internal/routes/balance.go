@@ -1,25 +1,82 @@
 package routes
 
 import (
+	"github.com/dw-account-service/internal/db/entity"
 	"github.com/dw-account-service/internal/handlers"
+	"github.com/dw-account-service/internal/openapi"
+	"github.com/dw-account-service/internal/utilities"
 	"github.com/gofiber/fiber/v2"
 )
 
-func initBalanceRoutes(router fiber.Router) {
+func initBalanceRoutes(router fiber.Router, spec *openapi.Spec) {
 	balanceHandler := handlers.NewBalanceHandler()
 
 	r := router.Group("/account")
-	r.Post("/balance/inquiry", func(c *fiber.Ctx) error {
+	handle(spec, r, fiber.MethodPost, "/balance/inquiry", openapi.Operation{
+		Summary:  "Inquiry account balance",
+		Tags:     []string{"balance"},
+		Roles:    readRoles,
+		Request:  entity.InquiryBalance{},
+		Response: entity.InquiryBalance{},
+	}, func(c *fiber.Ctx) error {
 		return balanceHandler.Inquiry(c, false)
 	})
 
+	handle(spec, r, fiber.MethodPost, "/balance/statement", openapi.Operation{
+		Summary:  "Account balance statement",
+		Tags:     []string{"balance"},
+		Roles:    readRoles,
+		Request:  entity.StatementRequest{},
+		Response: entity.AccountStatement{},
+	}, func(c *fiber.Ctx) error {
+		return balanceHandler.Statement(c, false)
+	})
+
+	handle(spec, r, fiber.MethodPost, "/balance/topup", openapi.Operation{
+		Summary:     "Topup account balance",
+		Description: "Idempotent on partnerRefNumber, processed transaction is returned for the same partnerRefNumber.",
+		Tags:        []string{"balance"},
+		Roles:       writeRoles,
+		Request:     entity.BalanceTransaction{},
+		Response:    entity.BalanceTransaction{},
+	}, func(c *fiber.Ctx) error {
+		return balanceHandler.Transaction(c, utilities.TransTypeTopUp)
+	})
+
+	handle(spec, r, fiber.MethodPost, "/balance/payment", openapi.Operation{
+		Summary:     "Deduct account balance for payment",
+		Description: "Idempotent on partnerRefNumber, processed transaction is returned for the same partnerRefNumber.",
+		Tags:        []string{"balance"},
+		Roles:       writeRoles,
+		Request:     entity.BalanceTransaction{},
+		Response:    entity.BalanceTransaction{},
+	}, func(c *fiber.Ctx) error {
+		return balanceHandler.Transaction(c, utilities.TransTypePayment)
+	})
+
 	// ---------------------------------------------------------------
 
 	r2 := router.Group("/merchant")
-	r2.Post("/balance/inquiry", func(c *fiber.Ctx) error {
+	handle(spec, r2, fiber.MethodPost, "/balance/inquiry", openapi.Operation{
+		Summary:  "Inquiry merchant balance",
+		Tags:     []string{"balance"},
+		Roles:    readRoles,
+		Request:  entity.InquiryBalance{},
+		Response: entity.InquiryBalance{},
+	}, func(c *fiber.Ctx) error {
 		return balanceHandler.Inquiry(c, true)
 	})
 
+	handle(spec, r2, fiber.MethodPost, "/balance/statement", openapi.Operation{
+		Summary:  "Merchant balance statement",
+		Tags:     []string{"balance"},
+		Roles:    readRoles,
+		Request:  entity.StatementRequest{},
+		Response: entity.AccountStatement{},
+	}, func(c *fiber.Ctx) error {
+		return balanceHandler.Statement(c, true)
+	})
+
 	//r2.Post("/balance/summary", func(c *fiber.Ctx) error {
 	//	return balanceHandler.MerchantBalanceSummary(c)
 	//})
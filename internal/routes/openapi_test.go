@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"bytes"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dw-account-service/internal/handlers"
+	"github.com/dw-account-service/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+var update = flag.Bool("update", false, "update golden api spec file")
+
+var goldenSpec = filepath.Join("testdata", "openapi.golden.json")
+
+// TestOpenAPISpec fails when request/response types or routes drift from the reviewed api spec,
+// run `go test ./internal/routes -update` and review the diff after intended api changes
+func TestOpenAPISpec(t *testing.T) {
+	app := fiber.New()
+	spec := registerRoutes(app.Group(apiPrefix))
+
+	got, err := spec.JSON()
+	if err != nil {
+		t.Fatalf("failed to generate api spec: %v", err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err = os.WriteFile(goldenSpec, got, 0644); err != nil {
+			t.Fatalf("failed to update golden api spec: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(goldenSpec)
+	if err != nil {
+		t.Fatalf("failed to read golden api spec: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("api spec drift from %s, run `go test ./internal/routes -update` and review the diff", goldenSpec)
+	}
+}
+
+// TestRoutesDocumented fails when a route is registered without its operation
+func TestRoutesDocumented(t *testing.T) {
+	app := fiber.New()
+	spec := registerRoutes(app.Group(apiPrefix))
+
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead || !strings.HasPrefix(route.Path, apiPrefix) {
+			continue
+		}
+
+		if !spec.Has(route.Method, route.Path) {
+			t.Errorf("route %s %s is not documented in api spec", route.Method, route.Path)
+		}
+	}
+}
+
+// elemType returns type of v without pointers, or nil
+func elemType(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// TestOpenAPIRequestTypes fails when a handler parses request body into other type than its documented request,
+// each route is called by admin caller with malformed json body, so the handler returns right after parsing it
+func TestOpenAPIRequestTypes(t *testing.T) {
+	app := fiber.New()
+	parsed := map[string]reflect.Type{}
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if body := c.Locals(handlers.LocalsRequestBody); body != nil {
+			parsed[c.Method()+" "+c.Route().Path] = elemType(body)
+		}
+		return err
+	})
+	app.Use(recover.New())
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.LocalsRoles, []string{middleware.RoleAdmin})
+		c.Locals(middleware.LocalsSubject, "admin@example.com")
+		return c.Next()
+	})
+	spec := registerRoutes(app.Group(apiPrefix))
+
+	for _, route := range app.GetRoutes(true) {
+		// multipart form fields are read one by one, see handlers.ReconciliationHandler.Upload
+		op, ok := spec.Operation(route.Method, route.Path)
+		if !ok || op.RequestContentType == fiber.MIMEMultipartForm {
+			continue
+		}
+
+		path := strings.ReplaceAll(route.Path, ":id", "000000000000000000000000")
+		req := httptest.NewRequest(route.Method, path, strings.NewReader("{"))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if _, err := app.Test(req, -1); err != nil {
+			t.Fatalf("%s %s: request failed: %v", route.Method, route.Path, err)
+		}
+
+		got, want := parsed[route.Method+" "+route.Path], elemType(op.Request)
+		if got != want {
+			t.Errorf("%s %s: handler parses %v, documented request is %v", route.Method, route.Path, got, want)
+		}
+	}
+}
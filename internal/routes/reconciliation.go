@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/handlers"
+	"github.com/dw-account-service/internal/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// reconciliationUpload is multipart form of reconciliation upload
+type reconciliationUpload struct {
+	File      string `json:"file"`
+	PartnerID string `json:"partnerId"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+}
+
+func initReconciliationRoutes(router fiber.Router, spec *openapi.Spec) {
+	reconciliationHandler := handlers.NewReconciliationHandler()
+
+	r := router.Group("/reconciliation")
+	handle(spec, r, fiber.MethodPost, "/upload", openapi.Operation{
+		Summary:            "Reconcile partner settlement file",
+		Description:        "file is partner settlement csv file, start and end are optional periods filter.",
+		Tags:               []string{"reconciliation"},
+		Roles:              readRoles,
+		Request:            reconciliationUpload{},
+		RequestContentType: fiber.MIMEMultipartForm,
+		Response:           entity.ReconciliationReport{},
+	}, func(c *fiber.Ctx) error {
+		return reconciliationHandler.Upload(c)
+	})
+}
@@ -1,61 +1,142 @@
 package routes
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/health"
+	"github.com/dw-account-service/internal/metrics"
+	"github.com/dw-account-service/internal/middleware"
+	"github.com/dw-account-service/internal/openapi"
+	"github.com/dw-account-service/internal/tracing"
 	"github.com/dw-account-service/internal/utilities"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"log"
-	"os"
 )
 
-func setupRoutes(app *fiber.App) {
+const apiPrefix = "/api/v1"
 
-	api := app.Group("/api/v1")
-	initAccountRoutes(api)
-	initBalanceRoutes(api)
-	//initMerchantRoutes(api)
+// server is the running rest api, see Shutdown
+var server *fiber.App
+
+var (
+	// readRoles can access inquiry endpoints, support role is read-only
+	readRoles = []string{middleware.RoleAdmin, middleware.RoleSupport, middleware.RolePartner}
+	// writeRoles can access endpoints that create or modify account
+	writeRoles = []string{middleware.RoleAdmin, middleware.RolePartner}
+	// adminRoles can access internal tools, which affect every account
+	adminRoles = []string{middleware.RoleAdmin}
+)
 
-	utilities.Log.Println("| routes >> initialized")
+// handle register route handlers restricted to the operation roles, and document the route into api spec
+func handle(spec *openapi.Spec, r fiber.Router, method, path string, op openapi.Operation, handlers ...fiber.Handler) {
+	register(spec, r, method, path, op, nil, handlers)
 }
 
-func getLogFile() *os.File {
-	// Define file to logs
-	f, err := os.OpenFile(configs.MainConfig.LogPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("error opening file: %v", err)
+// handleAudited register admin action route like handle, every call of the action is recorded into admin audit log,
+// including call which is rejected by the operation roles
+func handleAudited(spec *openapi.Spec, r fiber.Router, method, path, action string, op openapi.Operation, handlers ...fiber.Handler) {
+	register(spec, r, method, path, op, []fiber.Handler{middleware.AdminAudit(action)}, handlers)
+}
+
+// register document the route into api spec, and register the route with handlers in order: before, roles check, handlers
+func register(spec *openapi.Spec, r fiber.Router, method, path string, op openapi.Operation, before, handlers []fiber.Handler) {
+	prefix := ""
+	if group, ok := r.(*fiber.Group); ok {
+		prefix = group.Prefix
 	}
+	spec.Add(method, prefix+path, op)
 
-	return f
+	chain := append([]fiber.Handler{}, before...)
+	if len(op.Roles) > 0 {
+		chain = append(chain, middleware.RequireRoles(op.Roles...))
+	}
+	r.Add(method, path, append(chain, handlers...)...)
 }
 
-func getFiberLogConfig(a *fiber.App) {
-	var conf logger.Config
+// registerRoutes register every api route, and returns its api spec
+func registerRoutes(api fiber.Router) *openapi.Spec {
+	spec := openapi.New(configs.MainConfig.AppName, "1.0.0", "Wallet account service REST API")
 
-	if configs.MainConfig.DebugMode == true {
-		conf.Format = "[REST-API] ${time} | ${status} | ${latency} | ${method} | ${path}\n"
-		if configs.MainConfig.VerboseAPIResponse {
-			conf.Format = "[REST-API] ${time} | ${status} | ${latency} | ${method} | ${path} \n\tResponse: ${resBody}\n"
-		}
+	initAccountRoutes(api, spec)
+	initBalanceRoutes(api, spec)
+	initReconciliationRoutes(api, spec)
+	initWebhookRoutes(api, spec)
+	initAdminRoutes(api, spec)
+	//initMerchantRoutes(api)
 
-		conf.TimeFormat = "2006/01/02 15:04:05"
+	return spec
+}
 
-		if configs.MainConfig.LogOutput == "file" {
-			conf.Output = getLogFile()
+// setupRoutes register probes and metrics of every role, rest api routes are only registered on api role
+func setupRoutes(app *fiber.App) error {
+
+	app.Use(tracing.HTTPMiddleware())
+	app.Use(middleware.RequestLogger())
+	app.Use(metrics.HTTPMiddleware())
+
+	// probes and metrics for orchestration, doesn't require authentication
+	app.Get("/metrics", metrics.Handler())
+	app.Get("/healthz", func(c *fiber.Ctx) error {
+		return c.JSON(health.Live())
+	})
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		report := health.Ready(c.UserContext())
+		if report.Status != health.StatusUp {
+			c.Status(fiber.StatusServiceUnavailable)
 		}
+		return c.JSON(report)
+	})
 
-		a.Use(logger.New(conf))
+	if !configs.MainConfig.RunAPI() {
+		utilities.Component("rest-api").Info().Msg("probes and metrics initialized")
+		return nil
+	}
+
+	// api documentation doesn't require authentication
+	var specJSON []byte
+	app.Get(apiPrefix+"/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(specJSON)
+	})
+	app.Get(apiPrefix+"/docs", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(openapi.DocsHTML(apiPrefix + "/openapi.json"))
+	})
+
+	api := app.Group(apiPrefix)
+	authenticate, err := middleware.Authenticate()
+	if err != nil {
+		return err
 	}
+	api.Use(authenticate)
 
+	if configs.MainConfig.RateLimit.Enable {
+		api.Use(middleware.RateLimit(apiPrefix, middleware.NewMemoryRateLimitStore()))
+	}
+
+	// money-moving and inquiry endpoints require signed request
+	if configs.MainConfig.Auth.Signature.Enable {
+		signature := middleware.VerifySignature(middleware.NewNonceStore())
+		api.Use("/account", signature)
+		api.Use("/merchant", signature)
+	}
+
+	if specJSON, err = registerRoutes(api).JSON(); err != nil {
+		return err
+	}
+
+	utilities.Component("rest-api").Info().Msg("routes initialized")
+	return nil
 }
 
 func Initialize() error {
 
 	app := fiber.New()
-	getFiberLogConfig(app)
-	setupRoutes(app)
+	server = app
+	if err := setupRoutes(app); err != nil {
+		return err
+	}
 
 	err := app.Listen(fmt.Sprintf(":%s", configs.MainConfig.APIServer.Port))
 	if err != nil {
@@ -65,3 +146,12 @@ func Initialize() error {
 	return nil
 
 }
+
+// Shutdown stop accepting new requests and wait until in-flight requests are finished, or the context is done
+func Shutdown(ctx context.Context) error {
+	if server == nil {
+		return nil
+	}
+
+	return server.ShutdownWithContext(ctx)
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dw-account-service/internal/middleware"
+	"github.com/dw-account-service/internal/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRegisterOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		roles  []string
+		status int
+		called bool
+	}{
+		{"allowed", []string{middleware.RoleAdmin}, fiber.StatusOK, true},
+		{"rejected", []string{middleware.RoleSupport}, fiber.StatusForbidden, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded int
+			called := false
+
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals(middleware.LocalsRoles, tt.roles)
+				return c.Next()
+			})
+
+			// before handler (e.g. admin audit) sees the result of the roles check
+			before := func(c *fiber.Ctx) error {
+				err := c.Next()
+				recorded = c.Response().StatusCode()
+				return err
+			}
+			register(openapi.New("test", "1.0.0", ""), app, fiber.MethodPost, "/admin/action", openapi.Operation{Roles: adminRoles},
+				[]fiber.Handler{before}, []fiber.Handler{func(c *fiber.Ctx) error {
+					called = true
+					return c.SendStatus(fiber.StatusOK)
+				}})
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/admin/action", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.status || recorded != tt.status || called != tt.called {
+				t.Errorf("got status %d, recorded %d, handler called %v, want %d %v", resp.StatusCode, recorded, called, tt.status, tt.called)
+			}
+		})
+	}
+}
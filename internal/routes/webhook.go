@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/handlers"
+	"github.com/dw-account-service/internal/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+var partnerQuery = openapi.Param{Name: "partnerId", Description: "required for admin and support role"}
+
+func initWebhookRoutes(router fiber.Router, spec *openapi.Spec) {
+	webhookHandler := handlers.NewWebhookHandler()
+
+	r := router.Group("/webhook")
+	handle(spec, r, fiber.MethodPut, "/", openapi.Operation{
+		Summary:     "Set partner webhook endpoint",
+		Description: "Secret is generated when its empty, and only shown in this response.",
+		Tags:        []string{"webhook"},
+		Roles:       writeRoles,
+		Request:     entity.PartnerWebhookRequest{},
+		Response:    entity.PartnerWebhook{},
+	}, func(c *fiber.Ctx) error {
+		return webhookHandler.SetWebhook(c)
+	})
+
+	handle(spec, r, fiber.MethodGet, "/", openapi.Operation{
+		Summary:  "Get partner webhook endpoint",
+		Tags:     []string{"webhook"},
+		Roles:    readRoles,
+		Query:    []openapi.Param{partnerQuery},
+		Response: entity.PartnerWebhook{},
+	}, func(c *fiber.Ctx) error {
+		return webhookHandler.GetWebhook(c)
+	})
+
+	handle(spec, r, fiber.MethodGet, "/deliveries/failed", openapi.Operation{
+		Summary:   "List callback failed deliveries",
+		Tags:      []string{"webhook"},
+		Roles:     readRoles,
+		Query:     append([]openapi.Param{partnerQuery}, pageQuery...),
+		Response:  entity.WebhookDelivery{},
+		Paginated: true,
+	}, func(c *fiber.Ctx) error {
+		return webhookHandler.GetFailedDeliveries(c)
+	})
+
+	handle(spec, r, fiber.MethodPost, "/deliveries/:id/redeliver", openapi.Operation{
+		Summary:  "Redeliver callback failed delivery",
+		Tags:     []string{"webhook"},
+		Roles:    writeRoles,
+		Response: entity.WebhookDelivery{},
+	}, func(c *fiber.Ctx) error {
+		return webhookHandler.Redeliver(c)
+	})
+}
@@ -0,0 +1,178 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/utilities"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+const (
+	snapshotDateLayout = "20060102"
+	// snapshotTimeout is maximum duration of taking snapshot of every account
+	snapshotTimeout = 30 * time.Minute
+	// snapshotLease is how long scheduled snapshot of a date is not taken by other worker
+	snapshotLease = snapshotTimeout + time.Minute
+	// stopTimeout is additional time for cancelled snapshot to stop, after shutdown timeout is reached
+	stopTimeout = 5 * time.Second
+)
+
+var (
+	snapshotCancel context.CancelFunc
+	// snapshotDone is closed when daily snapshot loop has been stopped
+	snapshotDone chan struct{}
+)
+
+// TakeSnapshot store closing balance of every account at the end of the given date.
+// it returns total snapshot that has been successfully stored, taking snapshot is stopped when ctx is cancelled.
+func TakeSnapshot(ctx context.Context, date time.Time) (int, error) {
+	accountRepo := repository.NewAccountRepository()
+	statementRepo := repository.NewStatementRepository()
+	snapshotRepo := repository.NewSnapshotRepository()
+
+	y, m, d := date.Date()
+	endOfDay := time.Date(y, m, d, 23, 59, 59, int(time.Second-time.Millisecond), date.Location())
+	snapshotDate := endOfDay.Format(snapshotDateLayout)
+
+	ctx = utilities.WithLogger(ctx, utilities.Component("scheduler").With().Str("snapshotDate", snapshotDate).Logger())
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	total := 0
+	err := accountRepo.Iterate(ctx, bson.D{}, func(account *entity.AccountBalance) error {
+		closingBalance, err := statementRepo.BalanceAt(ctx, account, endOfDay)
+		if err != nil {
+			utilities.Logger(ctx).Error().Err(err).Str("accountId", account.ID).Msg("failed to calculate closing balance")
+			return nil
+		}
+
+		snapshotRepo.Entity = &entity.BalanceSnapshot{
+			AccountID:      account.ID,
+			PartnerID:      account.PartnerID,
+			MerchantID:     account.MerchantID,
+			TerminalID:     account.TerminalID,
+			Type:           account.Type,
+			SnapshotDate:   snapshotDate,
+			ClosingBalance: closingBalance,
+			CreatedAt:      time.Now().UnixMilli(),
+		}
+
+		if err = snapshotRepo.Upsert(ctx); err != nil {
+			utilities.Logger(ctx).Error().Err(err).Str("accountId", account.ID).Msg("failed to store snapshot")
+			return nil
+		}
+
+		total++
+		return nil
+	})
+
+	return total, err
+}
+
+// nextRun returns the next occurrence of runAt (hh:mm) after now
+func nextRun(now time.Time, runAt string) (time.Time, error) {
+	t, err := time.ParseInLocation("15:04", runAt, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot runAt value: %s", runAt)
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next, nil
+}
+
+// StartDailySnapshot run TakeSnapshot for the previous day, every day at configured snapshot.runAt,
+// until StopDailySnapshot is called. snapshot of each date is taken by single worker, see SnapshotRepository.ClaimRun
+func StartDailySnapshot() error {
+	runAt := configs.MainConfig.Snapshot.RunAt
+	if runAt == "" {
+		runAt = "00:05"
+	}
+
+	if _, err := nextRun(time.Now(), runAt); err != nil {
+		return err
+	}
+
+	logger := utilities.Component("scheduler")
+	snapshotRepo := repository.NewSnapshotRepository()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	snapshotCancel, snapshotDone = cancel, make(chan struct{})
+
+	go func() {
+		defer close(snapshotDone)
+
+		for {
+			next, _ := nextRun(time.Now(), runAt)
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				logger.Info().Msg("daily balance snapshot stopped")
+				return
+			case <-timer.C:
+			}
+
+			start := time.Now()
+			date := start.AddDate(0, 0, -1)
+			snapshotDate := date.Format(snapshotDateLayout)
+
+			claimed, err := snapshotRepo.ClaimRun(ctx, snapshotDate, snapshotLease)
+			if err != nil {
+				logger.Error().Err(err).Str("snapshotDate", snapshotDate).Msg("failed to claim daily balance snapshot")
+				continue
+			}
+			if !claimed {
+				logger.Info().Str("snapshotDate", snapshotDate).Msg("daily balance snapshot is taken by other worker")
+				continue
+			}
+
+			total, err := TakeSnapshot(ctx, date)
+			if err != nil {
+				// uncompleted snapshot can be taken again by the snapshot admin endpoint
+				logger.Error().Err(err).Str("snapshotDate", snapshotDate).Msg("daily balance snapshot error")
+			} else if err = snapshotRepo.CompleteRun(ctx, snapshotDate, total); err != nil {
+				logger.Error().Err(err).Str("snapshotDate", snapshotDate).Msg("failed to complete daily balance snapshot")
+			}
+			logger.Info().
+				Int("total", total).
+				Dur("duration", time.Since(start)).
+				Msg("daily balance snapshot finished")
+		}
+	}()
+
+	logger.Info().Str("runAt", runAt).Msg("daily balance snapshot scheduled")
+	return nil
+}
+
+// StopDailySnapshot stop scheduling daily snapshot, running snapshot is cancelled
+// and can be taken again by the snapshot admin endpoint. it waits until the scheduler is stopped
+func StopDailySnapshot(ctx context.Context) error {
+	if snapshotCancel == nil {
+		return nil
+	}
+	snapshotCancel()
+
+	select {
+	case <-snapshotDone:
+		return nil
+	case <-ctx.Done():
+	}
+
+	// cancelled snapshot is stopped on its next database operation
+	select {
+	case <-snapshotDone:
+		return nil
+	case <-time.After(stopTimeout):
+		return errors.New("daily balance snapshot is not stopped")
+	}
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"github.com/Shopify/sarama"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"net/http"
+)
+
+// requestHeaderCarrier read and write trace context from http request headers
+type requestHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c requestHeaderCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c requestHeaderCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c requestHeaderCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// consumerMessageCarrier read trace context from headers of consumed message
+type consumerMessageCarrier struct {
+	message *sarama.ConsumerMessage
+}
+
+func (c consumerMessageCarrier) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerMessageCarrier) Set(key, value string) {
+	c.message.Headers = append(c.message.Headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c consumerMessageCarrier) Keys() []string {
+	var keys []string
+	for _, header := range c.message.Headers {
+		if header != nil {
+			keys = append(keys, string(header.Key))
+		}
+	}
+	return keys
+}
+
+// producerMessageCarrier write trace context into headers of produced message
+type producerMessageCarrier struct {
+	message *sarama.ProducerMessage
+}
+
+func (c producerMessageCarrier) Get(key string) string {
+	for _, header := range c.message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerMessageCarrier) Set(key, value string) {
+	// existing header of the key is replaced, e.g. traceparent of the consumed message
+	for i, header := range c.message.Headers {
+		if string(header.Key) == key {
+			c.message.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.message.Headers = append(c.message.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerMessageCarrier) Keys() []string {
+	var keys []string
+	for _, header := range c.message.Headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
+
+// InjectHTTP write trace context of the context into outgoing http request headers
+func InjectHTTP(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// ExtractMessage returns context with trace context of the consumed message headers
+func ExtractMessage(ctx context.Context, message *sarama.ConsumerMessage) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, consumerMessageCarrier{message: message})
+}
+
+// InjectMessage write trace context of the context into produced message headers
+func InjectMessage(ctx context.Context, message *sarama.ProducerMessage) {
+	otel.GetTextMapPropagator().Inject(ctx, producerMessageCarrier{message: message})
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTPMiddleware continue trace of incoming request headers, the request span is set into user context of the request,
+// so handlers pass it as c.UserContext() into repositories and producer
+func HTTPMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaderCarrier{header: &c.Request().Header})
+
+		ctx, span := Start(ctx, fmt.Sprintf("HTTP %s", c.Method()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(c.Method()),
+				semconv.HTTPTarget(c.OriginalURL()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// route is only known after it has been matched
+		span.SetName(fmt.Sprintf("%s %s", c.Method(), c.Route().Path))
+		span.SetAttributes(semconv.HTTPRoute(c.Route().Path))
+
+		status := c.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("http status %d", status))
+		}
+
+		return err
+	}
+}
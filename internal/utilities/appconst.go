@@ -10,9 +10,20 @@ const (
 	AccountStatusDeactivated = "deactivated"
 	AccountStatusAll         = "all"
 
+	JobStatusPending     = "pending"
+	JobStatusProcessing  = "processing"
+	JobStatusCompleted   = "completed"
+	JobStatusInterrupted = "interrupted" // stopped by shutdown before every row is processed
+
 	TransTypeTopUp        = 1 //"Top-Up"
 	TransTypePayment      = 2 //"Payment"
 	TransTypeDistribution = 3 //"Distribution"
+	TransTypeAdjustment   = 4 //"Adjustment"
+
+	AdjustmentStatusPending  = "pending"
+	AdjustmentStatusApproved = "approved"
+	AdjustmentStatusRejected = "rejected"
+	AdjustmentStatusFailed   = "failed"
 
 	TrxStatusSuccess          = "00"
 	TrxStatusPending          = "01"
@@ -2,7 +2,9 @@ package crypt
 
 import (
 	"crypto/aes"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"strconv"
@@ -57,3 +59,46 @@ func DecryptAndConvert(key []byte, ct string) (int, error) {
 	}
 	return result, err
 }
+
+// GenerateAPIKey returns random key id and secret, api key is sent by client as <keyId>.<secret>
+func GenerateAPIKey() (string, string, error) {
+	keyID := make([]byte, 8)
+	if _, err := rand.Read(keyID); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(keyID), hex.EncodeToString(secret), nil
+}
+
+// GenerateSigningSecret returns random secret used as HMAC key for request/response signature
+func GenerateSigningSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// HashAPIKeySecret returns sha256 (hex) of api key secret, only the hash is stored into database
+func HashAPIKeySecret(secret string) string {
+	return SHA256Hex([]byte(secret))
+}
+
+// HMACSignature returns hex encoded HMAC-SHA256 of message with secret as the key
+func HMACSignature(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SHA256Hex returns hex encoded sha256 of data
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
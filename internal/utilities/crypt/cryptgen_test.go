@@ -0,0 +1,17 @@
+package crypt
+
+import "testing"
+
+func TestHashAPIKeySecret(t *testing.T) {
+	tests := map[string]string{
+		"":    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"abc": "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	}
+
+	for secret, want := range tests {
+		if got := HashAPIKeySecret(secret); got != want {
+			t.Errorf("HashAPIKeySecret(%q) = %s, want %s", secret, got, want)
+		}
+	}
+
+}
@@ -1,77 +1,133 @@
 package utilities
 
 import (
+	"context"
 	"fmt"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/natefinch/lumberjack.v2"
-	"log"
+	"io"
 	"os"
-	"sync"
+	"strings"
 	"time"
 )
 
+const (
+	defaultLogMaxSizeMB  = 100
+	defaultLogMaxBackups = 7
+	defaultLogMaxAgeDays = 30
+)
+
+// Log is application logger, it writes to stderr until SetAppLogger is called.
+// component and request scoped loggers are derived from it, see Component and Logger
+var Log = zerolog.New(os.Stderr).With().Timestamp().Logger()
+
 type AppLogger struct {
-	LogPath     string
-	LastLogDate string
+	// Output: os | file
+	Output  string
+	LogPath string
+	// Format: json | text
+	Format string
+	// Level: debug | info | warn | error, default: info
+	Level string
+
+	// log file is rotated by its size, rotated files are removed after max backups or max age
+	MaxSizeMB   int
+	MaxBackups  int
+	MaxAgeDays  int
 	CompressLog bool
-	DailyRotate bool
-	Logger      *lumberjack.Logger
 }
 
-var Log *log.Logger
-
 func (p *AppLogger) SetAppLogger() error {
-	if p.LogPath == "" {
-		return fmt.Errorf("unspecify log file path")
+	level := zerolog.InfoLevel
+	if p.Level != "" {
+		parsed, err := zerolog.ParseLevel(strings.ToLower(p.Level))
+		if err != nil {
+			return fmt.Errorf("invalid log level: %s", p.Level)
+		}
+		level = parsed
 	}
 
-	file, err := os.OpenFile(p.LogPath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		return err
+	var out io.Writer = os.Stdout
+	if p.Output == "file" || p.Output == "" {
+		if p.LogPath == "" {
+			return fmt.Errorf("unspecify log file path")
+		}
+
+		out = &lumberjack.Logger{
+			Filename:   p.LogPath,
+			MaxSize:    valueOrDefault(p.MaxSizeMB, defaultLogMaxSizeMB),
+			MaxBackups: valueOrDefault(p.MaxBackups, defaultLogMaxBackups),
+			MaxAge:     valueOrDefault(p.MaxAgeDays, defaultLogMaxAgeDays),
+			Compress:   p.CompressLog,
+			LocalTime:  true,
+		}
 	}
 
-	Log = log.New(file, "", log.Ldate|log.Ltime)
-	p.Logger = &lumberjack.Logger{
-		Filename:  p.LogPath,
-		Compress:  p.CompressLog,
-		LocalTime: true,
+	switch p.Format {
+	case "text":
+		out = zerolog.ConsoleWriter{Out: out, NoColor: true, TimeFormat: "2006/01/02 15:04:05"}
+	case "json", "":
+	default:
+		return fmt.Errorf("invalid log format: %s", p.Format)
 	}
 
-	Log.SetOutput(p.Logger)
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	Log = zerolog.New(out).Level(level).With().Timestamp().Logger()
+
+	// logger of context without logger, see Logger
+	zerolog.DefaultContextLogger = &Log
+
+	return nil
+}
 
-	p.LastLogDate = time.Now().Format("2006-01-02")
+func valueOrDefault(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
 
-	if p.DailyRotate == true {
-		wg := &sync.WaitGroup{}
+// Component returns logger of application component, e.g. consumer, producer, scheduler
+func Component(name string) *zerolog.Logger {
+	logger := Log.With().Str("component", name).Logger()
+	return &logger
+}
 
-		wg.Add(1)
-		go func() {
-			p.LogRotator()
-		}()
+// ContextLogger returns logger carried by the context without trace id, it is used to derive logger that is carried
+// by other context. application logger is returned when the context doesn't carry any logger
+func ContextLogger(ctx context.Context) *zerolog.Logger {
+	if ctx == nil {
+		return &Log
+	}
 
+	logger := zerolog.Ctx(ctx)
+	if logger.GetLevel() == zerolog.Disabled {
+		logger = &Log
 	}
 
-	return nil
+	return logger
 }
 
-// LogRotator :
-func (p *AppLogger) LogRotator() {
-	for {
-		// If Logger not set then skip
-		if p.Logger == nil {
-			continue
-		}
-		// Rotate Log If LastLog Date != Current Date
-		if p.LastLogDate != time.Now().Format("2006-01-02") {
-			// Set LastLogDate to Current Date
-			p.LastLogDate = time.Now().Format("2006-01-02")
-			err := p.Logger.Rotate()
-			if err != nil {
-				log.Println("Daily Log Rotator Err: ", err.Error())
-				return
-			}
-			log.Println("| Log Rotated")
-		}
-		// Sleep every 5 seconds
-		time.Sleep(time.Duration(5) * time.Second)
+// Logger returns logger carried by the context, with trace id of the context span.
+// trace id is only added when the logger is returned, so derived logger doesn't contain it twice
+func Logger(ctx context.Context) *zerolog.Logger {
+	logger := ContextLogger(ctx)
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		withTrace := logger.With().Str("traceId", spanContext.TraceID().String()).Logger()
+		return &withTrace
 	}
+
+	return logger
+}
+
+// WithLogger returns context carrying the logger, the logger is used by every log of the context operation
+func WithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	return logger.WithContext(ctx)
+}
+
+// WithFields returns context carrying logger of the context with the fields, e.g. account ids
+func WithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	return WithLogger(ctx, ContextLogger(ctx).With().Fields(fields).Logger())
 }
@@ -0,0 +1,53 @@
+package utilities
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoggerTraceID(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+
+	buf := new(bytes.Buffer)
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	ctx = WithLogger(ctx, zerolog.New(buf).With().Str("component", "test").Logger())
+
+	// fields are added more than once, e.g. by consumer and transaction handler
+	ctx = WithFields(ctx, map[string]interface{}{"partnerId": "MDL"})
+	ctx = WithFields(ctx, map[string]interface{}{"merchantId": "M001"})
+	Logger(ctx).Info().Msg("processed")
+
+	line := buf.String()
+	if n := strings.Count(line, `"traceId"`); n != 1 {
+		t.Errorf("got %d traceId fields, want 1: %s", n, line)
+	}
+	for _, field := range []string{`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`, `"component":"test"`, `"partnerId":"MDL"`, `"merchantId":"M001"`} {
+		if !strings.Contains(line, field) {
+			t.Errorf("log doesn't contain %s: %s", field, line)
+		}
+	}
+
+	// logger carried by the context doesn't contain trace id
+	buf.Reset()
+	ContextLogger(ctx).Info().Msg("derived")
+	if strings.Contains(buf.String(), `"traceId"`) {
+		t.Errorf("context logger contains trace id: %s", buf.String())
+	}
+}
+
+func TestLoggerWithoutContextLogger(t *testing.T) {
+	if got := Logger(context.Background()); got.GetLevel() == zerolog.Disabled {
+		t.Error("logger of context without logger is disabled")
+	}
+	var ctx context.Context
+	if got := ContextLogger(ctx); got != &Log {
+		t.Error("logger of nil context is not application logger")
+	}
+}
@@ -0,0 +1,34 @@
+package utilities
+
+import (
+	"context"
+	"github.com/Shopify/sarama"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// OAuthBearerTokenProvider provide SASL/OAUTHBEARER access token of oauth2 client credentials grant,
+// the token is reused until it expires
+type OAuthBearerTokenProvider struct {
+	source oauth2.TokenSource
+}
+
+func NewOAuthBearerTokenProvider(tokenURL, clientID, clientSecret string, scopes []string) *OAuthBearerTokenProvider {
+	conf := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       scopes,
+	}
+
+	return &OAuthBearerTokenProvider{source: conf.TokenSource(context.Background())}
+}
+
+func (p *OAuthBearerTokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := p.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return &sarama.AccessToken{Token: token.AccessToken}, nil
+}
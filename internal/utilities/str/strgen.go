@@ -43,6 +43,8 @@ func GenerateReceiptNumber(transType int, id string) string {
 		r = fmt.Sprintf("2000%s%s", tUnix, id)
 	case utilities.TransTypeDistribution:
 		r = fmt.Sprintf("3000%s%s", tUnix, id)
+	case utilities.TransTypeAdjustment:
+		r = fmt.Sprintf("4000%s%s", tUnix, id)
 	}
 
 	return r
@@ -0,0 +1,148 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+	rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+	workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+	sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	sheetFooter = `</sheetData></worksheet>`
+)
+
+// StreamWriter write single sheet xlsx document row by row,
+// so the rows doesn't need to be loaded into memory before written.
+type StreamWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func NewStreamWriter(w io.Writer, sheetName string) (*StreamWriter, error) {
+	z := zip.NewWriter(w)
+
+	escapedName := new(strings.Builder)
+	_ = xml.EscapeText(escapedName, []byte(sheetName))
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, escapedName.String())},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}
+
+	for _, part := range parts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	sw := &StreamWriter{zip: z, sheet: bufio.NewWriter(f)}
+	if _, err = sw.sheet.WriteString(sheetHeader); err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+// WriteRow append a row into the sheet. numeric values is written as number cell, others as text cell
+func (s *StreamWriter) WriteRow(values ...interface{}) error {
+	s.row++
+	if _, err := fmt.Fprintf(s.sheet, `<row r="%d">`, s.row); err != nil {
+		return err
+	}
+
+	for idx, value := range values {
+		ref := columnName(idx) + strconv.Itoa(s.row)
+
+		var err error
+		switch v := value.(type) {
+		case int:
+			_, err = fmt.Fprintf(s.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			_, err = fmt.Fprintf(s.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			_, err = fmt.Fprintf(s.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			if _, err = fmt.Fprintf(s.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref); err != nil {
+				return err
+			}
+			if err = xml.EscapeText(s.sheet, []byte(fmt.Sprint(v))); err != nil {
+				return err
+			}
+			_, err = s.sheet.WriteString(`</t></is></c>`)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Close finalize the sheet and the xlsx (zip) document, it doesn't close the underlying writer
+func (s *StreamWriter) Close() error {
+	if _, err := s.sheet.WriteString(sheetFooter); err != nil {
+		return err
+	}
+
+	if err := s.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return s.zip.Close()
+}
+
+// columnName convert zero based column index into sheet column name, e.g. 0 -> A, 27 -> AB
+func columnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,149 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+)
+
+type sheetXML struct {
+	Rows []struct {
+		Ref   string `xml:"r,attr"`
+		Cells []struct {
+			Ref   string `xml:"r,attr"`
+			Type  string `xml:"t,attr"`
+			Value string `xml:"v"`
+			Text  string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readPart returns content of the zip part of the xlsx document
+func readPart(t *testing.T, doc []byte, name string) []byte {
+	t.Helper()
+
+	z, err := zip.NewReader(bytes.NewReader(doc), int64(len(doc)))
+	if err != nil {
+		t.Fatalf("document is not a zip archive: %v", err)
+	}
+
+	f, err := z.Open(name)
+	if err != nil {
+		t.Fatalf("document doesn't contain %s: %v", name, err)
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+
+	return content
+}
+
+func TestStreamWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, err := NewStreamWriter(buf, `Statement <A&B>`)
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+
+	rows := [][]interface{}{
+		{"name", "amount", "ratio"},
+		{`<b>"Tom" & Jerry</b>`, int64(-15000), 0.25},
+		{"  padded  ", 7, "0012"},
+	}
+	for _, row := range rows {
+		if err = w.WriteRow(row...); err != nil {
+			t.Fatalf("failed to write row: %v", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	doc := buf.Bytes()
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		readPart(t, doc, part)
+	}
+
+	var book struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = xml.Unmarshal(readPart(t, doc, "xl/workbook.xml"), &book); err != nil {
+		t.Fatalf("workbook is not valid xml: %v", err)
+	}
+	if len(book.Sheets) != 1 || book.Sheets[0].Name != `Statement <A&B>` {
+		t.Errorf("unexpected sheets: %+v", book.Sheets)
+	}
+
+	var sheet sheetXML
+	if err = xml.Unmarshal(readPart(t, doc, "xl/worksheets/sheet1.xml"), &sheet); err != nil {
+		t.Fatalf("sheet is not valid xml: %v", err)
+	}
+
+	tests := []struct {
+		row, col int
+		ref      string
+		numeric  bool
+		want     string
+	}{
+		{0, 0, "A1", false, "name"},
+		{1, 0, "A2", false, `<b>"Tom" & Jerry</b>`},
+		{1, 1, "B2", true, "-15000"},
+		{1, 2, "C2", true, "0.25"},
+		{2, 0, "A3", false, "  padded  "},
+		{2, 1, "B3", true, "7"},
+		{2, 2, "C3", false, "0012"},
+	}
+
+	if len(sheet.Rows) != len(rows) {
+		t.Fatalf("got %d rows, want %d", len(sheet.Rows), len(rows))
+	}
+
+	for _, tt := range tests {
+		row := sheet.Rows[tt.row]
+		if len(row.Cells) != len(rows[tt.row]) {
+			t.Fatalf("row %s: got %d cells, want %d", row.Ref, len(row.Cells), len(rows[tt.row]))
+		}
+
+		cell := row.Cells[tt.col]
+		if cell.Ref != tt.ref {
+			t.Errorf("cell %s: got reference %s", tt.ref, cell.Ref)
+		}
+
+		if tt.numeric {
+			if cell.Type != "" || cell.Value != tt.want {
+				t.Errorf("cell %s: got type %q value %q, want number %s", tt.ref, cell.Type, cell.Value, tt.want)
+			}
+			continue
+		}
+
+		if cell.Type != "inlineStr" || cell.Text != tt.want {
+			t.Errorf("cell %s: got type %q text %q, want inline string %q", tt.ref, cell.Type, cell.Text, tt.want)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{
+		0:   "A",
+		25:  "Z",
+		26:  "AA",
+		27:  "AB",
+		51:  "AZ",
+		52:  "BA",
+		701: "ZZ",
+		702: "AAA",
+	}
+
+	for idx, want := range tests {
+		if got := columnName(idx); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", idx, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL        = errors.New("webhook url must be a valid https url")
+	ErrDisallowedAddress = errors.New("webhook url must resolve to a public address")
+)
+
+// sharedAddressSpace is carrier-grade NAT range (100.64.0.0/10), not covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP returns false for loopback, private, link-local (e.g. cloud metadata endpoint), multicast and unspecified address
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// ValidateURL check partner webhook url is https url, and every address of its host is public address.
+// the address is checked again when the callback is sent, see newClient
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !isPublicIP(ip) {
+			return ErrDisallowedAddress
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve webhook url host: %s", err.Error())
+	}
+
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return ErrDisallowedAddress
+		}
+	}
+
+	return nil
+}
+
+// dialControl rejects connection to non public address, so resolved address that is changed after
+// the url is validated (dns rebinding) cannot reach internal network
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return ErrDisallowedAddress
+	}
+
+	return nil
+}
+
+// newClient returns callback http client, it only connects to public address without proxy and doesn't follow redirects
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout(),
+		Control: dialControl,
+	}
+
+	return &http.Client{
+		Timeout: timeout(),
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        workerCount,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,319 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dw-account-service/configs"
+	"github.com/dw-account-service/internal/db/entity"
+	"github.com/dw-account-service/internal/db/repository"
+	"github.com/dw-account-service/internal/tracing"
+	"github.com/dw-account-service/internal/utilities"
+	"github.com/dw-account-service/internal/utilities/crypt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderDeliveryID = "X-Webhook-Id"
+	HeaderTimestamp  = "X-Timestamp"
+	HeaderSignature  = "X-Signature"
+
+	defaultMaxAttempts    = 6
+	defaultInitialBackoff = 10 * time.Second
+	defaultMaxBackoff     = time.Hour
+	defaultTimeout        = 10 * time.Second
+
+	// interval of due deliveries polling
+	pollInterval = 5 * time.Second
+	// number of concurrent delivery worker
+	workerCount = 4
+)
+
+var (
+	// wake notify workers that new delivery is queued
+	wake = make(chan struct{}, 1)
+
+	workerCancel context.CancelFunc
+	// workerDone is closed when every delivery worker has been stopped
+	workerDone chan struct{}
+
+	ErrNotRedeliverable = errors.New("only callback failed delivery can be redelivered")
+)
+
+// StringToSign returns callback message signed with partner webhook secret:
+// TIMESTAMP \n DELIVERY_ID \n hex(sha256(BODY))
+func StringToSign(timestamp, deliveryID string, body []byte) string {
+	return fmt.Sprintf("%s\n%s\n%s", timestamp, deliveryID, crypt.SHA256Hex(body))
+}
+
+func maxAttempts() int {
+	if configs.MainConfig.Webhook.MaxAttempts > 0 {
+		return configs.MainConfig.Webhook.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+func timeout() time.Duration {
+	if configs.MainConfig.Webhook.TimeoutSeconds > 0 {
+		return time.Duration(configs.MainConfig.Webhook.TimeoutSeconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+// backoff returns delay before next attempt, doubled on every failed attempt up to webhook.maxBackoffSeconds
+func backoff(attempts int) time.Duration {
+	initial, max := defaultInitialBackoff, defaultMaxBackoff
+	if configs.MainConfig.Webhook.InitialBackoffSeconds > 0 {
+		initial = time.Duration(configs.MainConfig.Webhook.InitialBackoffSeconds) * time.Second
+	}
+	if configs.MainConfig.Webhook.MaxBackoffSeconds > 0 {
+		max = time.Duration(configs.MainConfig.Webhook.MaxBackoffSeconds) * time.Second
+	}
+
+	delay := initial
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// Dispatch queue callback of transaction result to the partner webhook endpoint, if its configured
+func Dispatch(ctx context.Context, trx *entity.BalanceTransaction) {
+	if !configs.MainConfig.Webhook.Enable || trx == nil || trx.PartnerID == "" {
+		return
+	}
+
+	webhookRepo := repository.NewWebhookRepository()
+	webhook, err := webhookRepo.FindByPartnerID(ctx, trx.PartnerID)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			utilities.Logger(ctx).Error().Err(err).Str("partnerId", trx.PartnerID).Msg("failed to fetch partner webhook")
+		}
+		return
+	}
+
+	if !webhook.Active {
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	deliveryRepo := repository.NewWebhookDeliveryRepository()
+	deliveryRepo.Entity = &entity.WebhookDelivery{
+		PartnerID:     trx.PartnerID,
+		URL:           webhook.URL,
+		Payload:       *trx,
+		Status:        utilities.TrxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if _, err = deliveryRepo.Create(ctx); err != nil {
+		utilities.Logger(ctx).Error().Err(err).
+			Str("partnerId", trx.PartnerID).
+			Str("partnerRefNumber", trx.PartnerRefNumber).
+			Msg("failed to queue webhook delivery")
+		return
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver send single signed callback attempt, then store the attempt result.
+// delivery is marked as callback failed after webhook.maxAttempts failed attempts
+func deliver(ctx context.Context, client *http.Client, delivery *entity.WebhookDelivery) {
+	ctx, span := tracing.Start(ctx, "webhook.deliver", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("partnerId", delivery.PartnerID), attribute.String("deliveryId", delivery.ID)))
+	defer span.End()
+
+	delivery.Attempts++
+
+	err := send(ctx, client, delivery)
+	if err == nil {
+		delivery.Status = utilities.TrxStatusSuccess
+		delivery.LastError = ""
+		delivery.DeliveredAt = time.Now().UnixMilli()
+		delivery.NextAttemptAt = 0
+	} else {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= maxAttempts() {
+			delivery.Status = utilities.TrxStatusCallbackFailed
+			delivery.NextAttemptAt = 0
+			utilities.Logger(ctx).Warn().Err(err).
+				Str("deliveryId", delivery.ID).
+				Str("partnerId", delivery.PartnerID).
+				Int("attempts", delivery.Attempts).
+				Msg("webhook delivery failed after max attempts")
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts)).UnixMilli()
+		}
+	}
+
+	deliveryRepo := repository.NewWebhookDeliveryRepository()
+	if err = deliveryRepo.UpdateAttempt(ctx, delivery); err != nil {
+		utilities.Logger(ctx).Error().Err(err).Str("deliveryId", delivery.ID).Msg("failed to store webhook delivery attempt")
+	}
+}
+
+func send(ctx context.Context, client *http.Client, delivery *entity.WebhookDelivery) error {
+	// secret is taken from current configuration, so rotated secret is used for retries
+	webhookRepo := repository.NewWebhookRepository()
+	webhook, err := webhookRepo.FindByPartnerID(ctx, delivery.PartnerID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch partner webhook: %s", err.Error())
+	}
+
+	// url registered before https was required is not sent
+	if u, err := url.Parse(delivery.URL); err != nil || u.Scheme != "https" {
+		return ErrInvalidURL
+	}
+
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, crypt.HMACSignature(webhook.Secret, StringToSign(timestamp, delivery.ID, body)))
+	tracing.InjectHTTP(ctx, req.Header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		delivery.LastStatusCode = 0
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	delivery.LastStatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Redeliver move callback failed delivery back to the queue, it is sent by the delivery worker
+// and failed attempt is retried with backoff as new delivery
+func Redeliver(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	deliveryRepo := repository.NewWebhookDeliveryRepository()
+	delivery, err := deliveryRepo.ResetFailed(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotRedeliverable
+		}
+		return nil, err
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+
+	return delivery, nil
+}
+
+// work deliver due callbacks until ctx is cancelled, in-flight delivery is completed before it returns
+func work(ctx context.Context, client *http.Client) {
+	deliveryCtx := utilities.WithLogger(context.Background(), *utilities.Component("webhook"))
+	deliveryRepo := repository.NewWebhookDeliveryRepository()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			// lease must be longer than single attempt
+			delivery, err := deliveryRepo.ClaimDue(deliveryCtx, 2*timeout())
+			if err != nil {
+				if !errors.Is(err, mongo.ErrNoDocuments) {
+					utilities.Logger(deliveryCtx).Error().Err(err).Msg("failed to fetch due webhook deliveries")
+				}
+				break
+			}
+
+			deliver(deliveryCtx, client, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartDeliveryWorker start background workers that deliver queued and retried callbacks
+func StartDeliveryWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	workerCancel, workerDone = cancel, make(chan struct{})
+
+	client := newClient()
+	wg := new(sync.WaitGroup)
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(ctx, client)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(workerDone)
+	}()
+
+	utilities.Component("webhook").Info().Int("workers", workerCount).Msg("webhook delivery worker started")
+}
+
+// StopDeliveryWorker stop delivery workers and wait until in-flight deliveries are completed,
+// in-flight delivery is bounded by webhook.timeoutSeconds after ctx is done.
+// delivery which is not completed is attempted again after its lease is expired
+func StopDeliveryWorker(ctx context.Context) error {
+	if workerCancel == nil {
+		return nil
+	}
+	workerCancel()
+
+	select {
+	case <-workerDone:
+		utilities.Component("webhook").Info().Msg("webhook delivery worker stopped")
+		return nil
+	case <-ctx.Done():
+	}
+
+	select {
+	case <-workerDone:
+		return nil
+	case <-time.After(timeout()):
+		return errors.New("webhook delivery worker is not stopped, in-flight delivery is abandoned")
+	}
+}